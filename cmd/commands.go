@@ -1,10 +1,52 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/spf13/cobra"
+	"io.github.binatory/budich-cli/internal/cli"
+	"io.github.binatory/budich-cli/internal/domain"
+)
 
 var (
+	// list cmds flags
+	outputFlag   string
+	templateFlag string
+
 	// search cmd flags
 	connectorFlag string
+	libraryFlag   bool
+
+	// play cmd flags
+	sleepFlag    string
+	fromFlag     string
+	loopFlag     string
+	volumeFlag   int
+	autoplayFlag bool
+	queryFlag    string
+	pickFlag     bool
+	allFlag      bool
+	fileFlag     string
+
+	// history and stats cmd flags
+	sinceFlag   string
+	untilFlag   string
+	searchFlag  string
+	skippedFlag bool
+	limitFlag   int
+	topFlag     int
+
+	// playlist import and export cmd flags
+	formatFlag  string
+	resolveFlag bool
+	saveFlag    string
+	noPlayFlag  bool
+
+	// playlist fetch cmd flags
+	nameFlag string
+
+	// charts cmd flags
+	kindFlag   string
+	regionFlag string
+	playFlag   bool
 )
 
 var searchCmd = &cobra.Command{
@@ -12,25 +54,487 @@ var searchCmd = &cobra.Command{
 	Short: "search for songs/playlists/artists by name",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return executor.Search(connectorFlag, args[0])
+		if libraryFlag {
+			return executor.SearchLibrary(args[0])
+		}
+		return executor.Search(connectorOrDefault(), args[0])
+	},
+}
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest [prefix]",
+	Short: "complete a search term with the past searches and the suggestions of the connectors",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+		return executor.Suggest(prefix)
 	},
 }
 
 var playCmd = &cobra.Command{
-	Use:   "play <song_id>",
-	Short: "play a song by id",
+	Use:   "play <song_id>|<url>|-",
+	Short: "play a song by id (e.g. zmp3:ZWAFE8BC), by its share url or by searching for it, - plays the songs listed on the standard input",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if queryFlag != "" || fileFlag != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		opts.From, opts.Loop = fromFlag, loopFlag
+		if queryFlag != "" {
+			return executor.PlaySearch(queryFlag, cli.SearchPlayOptions{
+				PlayOptions: opts,
+				Connector:   connectorFlag,
+				Pick:        pickFlag,
+				All:         allFlag,
+			})
+		}
+		if fileFlag != "" {
+			return executor.PlayBatch(fileFlag, opts)
+		}
+		if args[0] == "-" {
+			return executor.PlayBatch("-", opts)
+		}
+		return executor.Play(args[0], opts)
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "resume the queue of the last session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		return executor.Resume(opts)
+	},
+}
+
+func playOptions() (cli.PlayOptions, error) {
+	sleep, err := domain.ParseSleepTimer(sleepFlag)
+	if err != nil {
+		return cli.PlayOptions{}, err
+	}
+	return cli.PlayOptions{Sleep: sleep, Volume: volumeFlag, Autoplay: autoplayFlag}, nil
+}
+
+func addPlaybackFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sleepFlag, "sleep", "", "stop after a duration (e.g. 30m) or after the current song (\"song\")")
+	cmd.Flags().IntVar(&volumeFlag, "volume", 0, "volume in percent, from 1 to 100")
+	cmd.Flags().BoolVar(&autoplayFlag, "autoplay", false, "keep playing related songs once the queue runs out")
+}
+
+var bookmarkCmd = &cobra.Command{
+	Use:   "bookmark",
+	Short: "manage the bookmarks of a song",
+}
+
+var bookmarkListCmd = &cobra.Command{
+	Use:   "list <song_id>",
+	Short: "list the bookmarks of a song",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.Bookmarks(args[0])
+	},
+}
+
+var bookmarkAddCmd = &cobra.Command{
+	Use:   "add <song_id> <name> <timestamp>",
+	Short: "bookmark a timestamp (e.g. 1:30) of a song",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.AddBookmark(args[0], args[1], args[2])
+	},
+}
+
+var bookmarkRemoveCmd = &cobra.Command{
+	Use:   "rm <song_id> <name>",
+	Short: "remove a bookmark of a song",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.RemoveBookmark(args[0], args[1])
+	},
+}
+
+var favCmd = &cobra.Command{
+	Use:   "fav",
+	Short: "manage the favorite songs",
+}
+
+var favListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the favorite songs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.Favorites()
+	},
+}
+
+var favAddCmd = &cobra.Command{
+	Use:   "add <song_id>",
+	Short: "add a song to the favorites",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.AddFavorite(args[0])
+	},
+}
+
+var favRemoveCmd = &cobra.Command{
+	Use:   "rm <song_id>",
+	Short: "remove a song from the favorites",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.RemoveFavorite(args[0])
+	},
+}
+
+var favPlayCmd = &cobra.Command{
+	Use:   "play",
+	Short: "play the favorite songs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		return executor.PlayFavorites(opts)
+	},
+}
+
+var playlistCmd = &cobra.Command{
+	Use:   "playlist",
+	Short: "manage the playlists",
+}
+
+var playlistListCmd = &cobra.Command{
+	Use:   "list [name]",
+	Short: "list the playlists, or the songs of a playlist",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return executor.Playlists(name)
+	},
+}
+
+var playlistCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create an empty playlist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.CreatePlaylist(args[0])
+	},
+}
+
+var playlistDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "delete a playlist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.DeletePlaylist(args[0])
+	},
+}
+
+var playlistAddCmd = &cobra.Command{
+	Use:   "add <name> <song_id>...",
+	Short: "add songs to a playlist",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.AddToPlaylist(args[0], args[1:]...)
+	},
+}
+
+var playlistRemoveCmd = &cobra.Command{
+	Use:   "remove <name> <song_id>",
+	Short: "remove a song from a playlist",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.RemoveFromPlaylist(args[0], args[1])
+	},
+}
+
+var playlistPlayCmd = &cobra.Command{
+	Use:   "play <name>",
+	Short: "play the songs of a playlist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		return executor.PlayPlaylist(args[0], opts)
+	},
+}
+
+var playlistExportCmd = &cobra.Command{
+	Use:   "export <name> <file>",
+	Short: "export a playlist to a m3u8 or xspf file, - writes to the standard output",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.ExportPlaylist(args[0], args[1], cli.ExportOptions{Format: formatFlag, Resolve: resolveFlag})
+	},
+}
+
+var playlistImportCmd = &cobra.Command{
+	Use:   "import <file_or_url>",
+	Short: "play the songs of a m3u8 or xspf playlist",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return executor.Play(args[0])
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		return executor.ImportPlaylist(args[0], cli.ImportOptions{
+			PlayOptions: opts,
+			Format:      formatFlag,
+			Save:        saveFlag,
+			NoPlay:      noPlayFlag,
+		})
+	},
+}
+
+var playlistFetchCmd = &cobra.Command{
+	Use:   "fetch <connector>:<playlist_id>|<url>",
+	Short: "copy a playlist of a streaming service into the library",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.FetchPlaylist(args[0], nameFlag)
+	},
+}
+
+var playlistSyncCmd = &cobra.Command{
+	Use:   "sync [name]",
+	Short: "add the new songs of the fetched playlists",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return executor.SyncPlaylists(name)
 	},
 }
 
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "list the songs played",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.History(cli.HistoryOptions{
+			Since:     sinceFlag,
+			Until:     untilFlag,
+			Connector: connectorFlag,
+			Term:      searchFlag,
+			Skipped:   skippedFlag,
+			Limit:     limitFlag,
+		})
+	},
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show the top songs, artists and connectors played",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.Stats(cli.StatsOptions{
+			Since: sinceFlag,
+			Until: untilFlag,
+			Top:   topFlag,
+		})
+	},
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info <song_id>|<url>",
+	Short: "show the details of a song: artists, album, release year, genres, cover art",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.Info(args[0])
+	},
+}
+
+var lyricsCmd = &cobra.Command{
+	Use:   "lyrics <song_id>|<url>",
+	Short: "print the lyrics of a song, the ones of a local song are read from the .lrc file next to it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.Lyrics(args[0])
+	},
+}
+
+var chartsCmd = &cobra.Command{
+	Use:   "charts",
+	Short: "list the top songs or the new releases of a streaming service",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := playOptions()
+		if err != nil {
+			return err
+		}
+		return executor.Charts(connectorOrDefault(), cli.ChartOptions{
+			PlayOptions: opts,
+			Kind:        kindFlag,
+			Region:      regionFlag,
+			Play:        playFlag,
+		})
+	},
+}
+
+// connectorOrDefault returns the connector flag, or the default connector of the preferences when it is not set
+func connectorOrDefault() string {
+	if connectorFlag != "" {
+		return connectorFlag
+	}
+	return app.Preferences().DefaultConnector
+}
+
+// addOutputFlags lets a command listing items print them as structured data
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", string(cli.OutputTable), "table, json, jsonl, csv, tsv or template")
+	cmd.Flags().StringVar(&templateFlag, "template", "", "Go template executed for every item, e.g. '{{.Ref}} {{.Name}}', implies --output template")
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		output := outputFlag
+		if !cmd.Flags().Changed("output") {
+			output = ""
+		}
+		return executor.SetOutput(output, templateFlag)
+	}
+}
+
+func addTimeRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sinceFlag, "since", "", "start of the time range, a date (2006-01-02) or a duration ago (e.g. 7d)")
+	cmd.Flags().StringVar(&untilFlag, "until", "", "end of the time range, a date (2006-01-02) or a duration ago (e.g. 7d)")
+}
+
 func init() {
 	// setup searchCmd
-	searchCmd.Flags().StringVarP(&connectorFlag, "connector", "c", "", "connector name (required)")
-	searchCmd.MarkFlagRequired("connector")
+	searchCmd.Flags().StringVarP(&connectorFlag, "connector", "c", "", "connector name, the defaultConnector setting by default")
+	searchCmd.Flags().BoolVarP(&libraryFlag, "library", "l", false, "search the favorites and the playlists instead, tone marks are optional")
+	addOutputFlags(searchCmd)
+
+	// setup playCmd
+	addPlaybackFlags(playCmd)
+	playCmd.Flags().StringVar(&fromFlag, "from", "", "start at a timestamp (e.g. 1:30) or a bookmark")
+	playCmd.Flags().StringVar(&loopFlag, "loop", "", "loop between A and B, given as <A>-<B> timestamps or bookmarks")
+	playCmd.Flags().StringVarP(&queryFlag, "search", "s", "", "play the song best matching a search instead of an id")
+	playCmd.Flags().StringVarP(&connectorFlag, "connector", "c", "", "search with this connector only, every one by default")
+	playCmd.Flags().BoolVar(&pickFlag, "pick", false, "choose the song to play among the search results")
+	playCmd.Flags().BoolVar(&allFlag, "all", false, "queue every search result")
+	playCmd.Flags().StringVarP(&fileFlag, "file", "f", "", "play the songs listed in a file, one ref or url per line")
+
+	// setup resumeCmd
+	addPlaybackFlags(resumeCmd)
+
+	// setup bookmarkCmd
+	bookmarkCmd.AddCommand(bookmarkListCmd)
+	bookmarkCmd.AddCommand(bookmarkAddCmd)
+	bookmarkCmd.AddCommand(bookmarkRemoveCmd)
+	addOutputFlags(bookmarkListCmd)
+
+	// setup favCmd
+	addPlaybackFlags(favPlayCmd)
+	addOutputFlags(favListCmd)
+	favCmd.AddCommand(favListCmd)
+	favCmd.AddCommand(favAddCmd)
+	favCmd.AddCommand(favRemoveCmd)
+	favCmd.AddCommand(favPlayCmd)
+
+	// setup playlistCmd
+	addPlaybackFlags(playlistPlayCmd)
+	addOutputFlags(playlistListCmd)
+	playlistCmd.AddCommand(playlistListCmd)
+	playlistCmd.AddCommand(playlistCreateCmd)
+	playlistCmd.AddCommand(playlistDeleteCmd)
+	playlistCmd.AddCommand(playlistAddCmd)
+	playlistCmd.AddCommand(playlistRemoveCmd)
+	playlistCmd.AddCommand(playlistPlayCmd)
+
+	playlistExportCmd.Flags().StringVar(&formatFlag, "format", "", "m3u8 or xspf, guessed from the file name by default")
+	playlistExportCmd.Flags().BoolVar(&resolveFlag, "resolve", false, "write the streaming urls, which may expire, instead of the song ids")
+	playlistCmd.AddCommand(playlistExportCmd)
+
+	addPlaybackFlags(playlistImportCmd)
+	playlistImportCmd.Flags().StringVar(&formatFlag, "format", "", "m3u8 or xspf, guessed from the file name by default")
+	playlistImportCmd.Flags().StringVar(&saveFlag, "save", "", "also copy the songs to a playlist of the library")
+	playlistImportCmd.Flags().BoolVar(&noPlayFlag, "no-play", false, "list the songs instead of playing them")
+	addOutputFlags(playlistImportCmd)
+	playlistCmd.AddCommand(playlistImportCmd)
+
+	playlistFetchCmd.Flags().StringVar(&nameFlag, "name", "", "name of the playlist in the library, the service one by default")
+	playlistCmd.AddCommand(playlistFetchCmd)
+	playlistCmd.AddCommand(playlistSyncCmd)
+
+	// setup historyCmd
+	addTimeRangeFlags(historyCmd)
+	historyCmd.Flags().StringVarP(&connectorFlag, "connector", "c", "", "only the songs of a connector")
+	historyCmd.Flags().StringVar(&searchFlag, "search", "", "only the songs or artists matching a term")
+	historyCmd.Flags().BoolVar(&skippedFlag, "skipped", false, "only the skipped songs")
+	historyCmd.Flags().IntVarP(&limitFlag, "limit", "n", 50, "number of most recent songs to list, 0 for all")
+	addOutputFlags(historyCmd)
+
+	// setup statsCmd
+	addTimeRangeFlags(statsCmd)
+	statsCmd.Flags().IntVar(&topFlag, "top", 10, "number of songs, artists and connectors to show, 0 for all")
+	addOutputFlags(statsCmd)
+
+	// setup chartsCmd
+	addPlaybackFlags(chartsCmd)
+	chartsCmd.Flags().StringVarP(&connectorFlag, "connector", "c", "", "connector name, the defaultConnector setting by default")
+	chartsCmd.Flags().StringVarP(&kindFlag, "kind", "k", string(domain.ChartTop), "top or new (releases)")
+	chartsCmd.Flags().StringVar(&regionFlag, "region", domain.DefaultChartRegion, "vn, us (US-UK) or kr (Korea)")
+	chartsCmd.Flags().BoolVar(&playFlag, "play", false, "queue the whole chart instead of listing it")
+	addOutputFlags(chartsCmd)
+
+	// setup configCmd
+	addOutputFlags(configListCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
 
 	// add sub commands to root
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(suggestCmd)
 	rootCmd.AddCommand(playCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(bookmarkCmd)
+	rootCmd.AddCommand(favCmd)
+	rootCmd.AddCommand(playlistCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(lyricsCmd)
+	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(chartsCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(configCmd)
+
+	// setup completions
+	for _, cmd := range []*cobra.Command{searchCmd, playCmd, historyCmd, chartsCmd} {
+		if err := cmd.RegisterFlagCompletionFunc("connector", completeConnectors); err != nil {
+			panic(err)
+		}
+	}
+	for _, cmd := range []*cobra.Command{playCmd, infoCmd, lyricsCmd, bookmarkListCmd, bookmarkAddCmd, bookmarkRemoveCmd, favAddCmd, favRemoveCmd} {
+		cmd.ValidArgsFunction = completeSong
+	}
+	for _, cmd := range []*cobra.Command{playlistListCmd, playlistDeleteCmd, playlistPlayCmd, playlistSyncCmd} {
+		cmd.ValidArgsFunction = completePlaylists
+	}
+	playlistAddCmd.ValidArgsFunction = completePlaylistThenSongs(false)
+	playlistRemoveCmd.ValidArgsFunction = completePlaylistThenSongs(true)
+	playlistExportCmd.ValidArgsFunction = completePlaylistThenFile
+	configGetCmd.ValidArgsFunction = completeConfigKeys
+	configSetCmd.ValidArgsFunction = completeConfigKeys
 }
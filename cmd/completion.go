@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "generate the shell completion script",
+	Long: `Generate the shell completion script, e.g.
+
+  bash: source <(budich completion bash)
+  zsh:  budich completion zsh > "${fpath[1]}/_budich"
+  fish: budich completion fish > ~/.config/fish/completions/budich.fish`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		default:
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		}
+	},
+}
+
+// completing tells whether the shell is asking for a completion script or for completions, which must be quick
+func completing() bool {
+	if len(os.Args) < 2 {
+		return false
+	}
+	switch os.Args[1] {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd, completionCmd.Name():
+		return true
+	}
+	return false
+}
+
+func completeConnectors(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return executor.CompleteConnectors(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSong completes the song id of the commands taking it first
+func completeSong(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	songs, err := executor.CompleteSongs(toComplete)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return songs, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completePlaylists(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := executor.CompletePlaylists(toComplete)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePlaylistThenSongs completes the playlist name then the song ids, of any song or of the playlist ones only
+func completePlaylistThenSongs(playlistSongs bool) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completePlaylists(cmd, args, toComplete)
+		}
+
+		var songs []string
+		var err error
+		if playlistSongs {
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			songs, err = executor.CompletePlaylistSongs(args[0], toComplete)
+		} else {
+			songs, err = executor.CompleteSongs(toComplete)
+		}
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return songs, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completePlaylistThenFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completePlaylists(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys, err := executor.CompleteConfigKeys(config, toComplete)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage the settings, read from the config file then overridden by the BD_* environment variables",
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the settings with their values and where the values come from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.ConfigList(config)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.ConfigGet(config, args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "write a setting to the config file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.ConfigSet(config, args[0], args[1])
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "open the config file in $VISUAL or $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return executor.ConfigEdit(config)
+	},
+}
+
+// configuring tells whether a config command is running, it must run even when the config file is invalid
+func configuring() bool {
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && (cmd == configCmd || cmd.Parent() == configCmd)
+}
@@ -15,18 +15,8 @@ var (
 	logFullPath string
 )
 
-func initLogger(verbose bool) {
-	// create log dir if not exists
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err)
-	}
-	logDir := filepath.Join(homeDir, ".budich-cli")
-	logFilename := "output.log"
-	logFullPath = filepath.Join(logDir, logFilename)
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		panic(err)
-	}
+func initLogger(verbose bool, logPath string) {
+	logFullPath = logPath
 
 	// setup logger
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
@@ -55,6 +45,9 @@ func ensureLogFile() {
 		return
 	}
 
+	if err := os.MkdirAll(filepath.Dir(logFullPath), 0755); err != nil {
+		panic(err)
+	}
 	var err error
 	logFile, err = os.OpenFile(logFullPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
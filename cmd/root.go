@@ -5,11 +5,16 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"io.github.binatory/budich-cli/internal/cli"
+	"io.github.binatory/budich-cli/internal/speaker"
+	"io.github.binatory/budich-cli/internal/utils"
 	"io.github.binatory/budich-cli/metadata"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
 	"runtime"
+	"sync"
+	"syscall"
 
 	"io.github.binatory/budich-cli/internal/domain"
 )
@@ -17,9 +22,13 @@ import (
 var (
 	// root flags
 	verboseFlag bool
+	sinkFlag    string
 
-	app      domain.App
-	executor *cli.CLI
+	config    domain.Config
+	sink      domain.Sink
+	closeOnce sync.Once
+	app       domain.App
+	executor  *cli.CLI
 )
 
 var rootCmd = &cobra.Command{
@@ -32,8 +41,28 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	cobra.OnInitialize(func() {
+		// load the preferences
+		dataDir, err := utils.DataDir()
+		if err != nil {
+			panic(err)
+		}
+		configPath, err := utils.ConfigPath()
+		if err != nil {
+			panic(err)
+		}
+		defaults := domain.DefaultPreferences(dataDir)
+		config = domain.NewConfig(configPath, defaults, os.Getenv)
+		prefs, err := config.Preferences()
+		if err != nil {
+			if !configuring() {
+				panic(err)
+			}
+			// the config commands must run to fix the file
+			prefs = defaults
+		}
+
 		// setup logger
-		initLogger(verboseFlag)
+		initLogger(verboseFlag, prefs.LogPath)
 
 		// setup profiler
 		if _, ok := os.LookupEnv("BD_PROFILING"); ok {
@@ -50,7 +79,23 @@ func init() {
 		}
 
 		// setup core
-		app = domain.DefaultApp()
+		sinkSpec := prefs.Sink
+		if rootCmd.PersistentFlags().Changed("sink") {
+			sinkSpec = sinkFlag
+		}
+		if sink, err = domain.NewSink(sinkSpec, prefs.SampleRate, speaker.NewSink); err != nil {
+			panic(err)
+		}
+		go closeSinkOnSignal()
+		app = domain.DefaultApp(prefs, sink, dataDir)
+
+		// setup CLI implementation
+		executor = cli.New(os.Stdout, app)
+
+		// the completions and the config commands only need the local storage, the connectors are not needed
+		if completing() || configuring() {
+			return
+		}
 
 		// check for updates
 		updateStatus, err := app.CheckForUpdate()
@@ -66,13 +111,11 @@ func init() {
 		if err := app.Init(); err != nil {
 			panic(err)
 		}
-
-		// setup CLI implementation
-		executor = cli.New(os.Stdout, app)
 	})
 
 	// setup rootCmd
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "enable logs verbosity")
+	rootCmd.PersistentFlags().StringVar(&sinkFlag, "sink", domain.SinkSpeaker, "audio output: speaker, null or wav:<path>, overrides the sink setting and BD_SINK")
 }
 
 func Execute() error {
@@ -86,5 +129,29 @@ func Execute() error {
 	if err != nil {
 		log.Error().Msgf("%+v", err)
 	}
+
+	closeSink()
 	return err
 }
+
+// closeSink closes the audio output once, the wav one writes its header then
+func closeSink() {
+	closeOnce.Do(func() {
+		if sink == nil {
+			return
+		}
+		if err := sink.Close(); err != nil {
+			log.Error().Msgf("error closing audio output: %+v", err)
+		}
+	})
+}
+
+// closeSinkOnSignal closes the audio output before exiting on interrupt, so the file of a wav sink stays readable
+func closeSinkOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	closeSink()
+	os.Exit(1)
+}
@@ -3,7 +3,7 @@ module io.github.binatory/budich-cli
 go 1.16
 
 require (
-	github.com/blang/semver/v4 v4.0.0 // indirect
+	github.com/blang/semver/v4 v4.0.0
 	github.com/faiface/beep v1.0.2
 	github.com/gdamore/tcell/v2 v2.3.3
 	github.com/pkg/errors v0.9.1
@@ -11,4 +11,6 @@ require (
 	github.com/rs/zerolog v1.23.0
 	github.com/spf13/cobra v1.2.1
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io.github.binatory/budich-cli/internal/domain"
+	"os"
+	"strings"
+)
+
+// PlayBatch plays in a queue the songs listed in a file, or in the standard input when path is -.
+// Every line holds a song ref or a share url, the rest of the line is ignored so that the tables
+// and the jsonl output of the other commands can be piped in. Invalid lines are reported and skipped
+func (c *CLI) PlayBatch(path string, opts PlayOptions) error {
+	r := c.in
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrapf(err, "error opening %s", path)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	songs, err := c.readBatch(r)
+	if err != nil {
+		return err
+	}
+	return c.playSongs(songs, opts)
+}
+
+func (c *CLI) readBatch(r io.Reader) ([]domain.Song, error) {
+	var songs []domain.Song
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		song, err := c.batchSong(line)
+		if err != nil {
+			fmt.Fprintf(c.errOut, "line %d skipped: %s\n", lineNo, err)
+			continue
+		}
+		songs = append(songs, song)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading the songs")
+	}
+	return songs, nil
+}
+
+// batchSong reads the song of a line, either a song record of the jsonl output or a ref or url followed by anything
+func (c *CLI) batchSong(line string) (domain.Song, error) {
+	if strings.HasPrefix(line, "{") {
+		var record songRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return domain.Song{}, errors.Wrap(err, "invalid song record")
+		}
+		if record.Id == "" || !c.isBatchConnector(record.Connector) {
+			return domain.Song{}, errors.Errorf("song record %s is not a song of a connector", line)
+		}
+		return record.song(), nil
+	}
+
+	song, err := c.parseSong(strings.Fields(line)[0])
+	if err != nil {
+		return domain.Song{}, err
+	}
+	if !c.isBatchConnector(song.Connector) {
+		return domain.Song{}, errors.Errorf("connector %s not recognized", song.Connector)
+	}
+	return song, nil
+}
+
+// isBatchConnector accepts the direct songs as well, as written for the local files and the urls of the playlists
+func (c *CLI) isBatchConnector(name string) bool {
+	return name == domain.DirectConnector || c.isConnector(name)
+}
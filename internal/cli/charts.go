@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"io.github.binatory/budich-cli/internal/domain"
+	"text/tabwriter"
+)
+
+type ChartOptions struct {
+	PlayOptions
+	Kind   string // top or new
+	Region string
+	Play   bool // queue the whole chart instead of listing it
+}
+
+// Charts lists the songs of a chart of a connector by rank, or plays them
+func (c *CLI) Charts(connector string, opts ChartOptions) error {
+	kind, err := domain.ParseChartKind(opts.Kind)
+	if err != nil {
+		return err
+	}
+	region, err := domain.ParseChartRegion(opts.Region)
+	if err != nil {
+		return err
+	}
+
+	chart, err := c.app.Chart(connector, kind, region)
+	if err != nil {
+		return err
+	}
+	if opts.Play {
+		return c.playSongs(chart.Songs, opts.PlayOptions)
+	}
+	if c.output != OutputTable {
+		// the songs are written by rank
+		return c.writeRecords(songRecords(chart.Songs))
+	}
+
+	fmt.Fprintln(c.out, chart.Name)
+	fmt.Fprintln(c.out)
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "#\tId\tBài hát\tCa sĩ")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "---\t----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for idx, s := range chart.Songs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s", idx+1, s.Ref(), s.Name, s.Artists)
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
@@ -5,27 +5,63 @@ import (
 	"github.com/pkg/errors"
 	"io"
 	"io.github.binatory/budich-cli/internal/domain"
+	"io.github.binatory/budich-cli/internal/utils"
+	"os"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 )
 
+const sessionSaveInterval = 5 * time.Second
+
 type CLI struct {
+	in             io.Reader
 	out            io.Writer
+	errOut         io.Writer // where the problems not failing a command are reported
 	app            domain.App
 	reportInterval time.Duration
+
+	// how the lists are printed
+	output   OutputFormat
+	template *template.Template
 }
 
 func New(out io.Writer, app domain.App) *CLI {
-	return &CLI{out, app, time.Second}
+	return &CLI{in: os.Stdin, out: out, errOut: os.Stderr, app: app, reportInterval: time.Second, output: OutputTable}
 }
 
+// Search prints the songs matching term ranked by relevance, the duplicates found on other connectors are listed with them
 func (c *CLI) Search(connector, term string) error {
 	songs, err := c.app.Search(connector, term)
 	if err != nil {
 		return err
 	}
 
+	groups := domain.RankSongs(term, songs)
+	if c.output != OutputTable {
+		return c.writeRecords(searchRecords(groups))
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "Id\tBài hát\tCa sĩ\tNguồn khác")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s", g.Ref(), g.Name, g.Artists, strings.Join(alternativeRefs(g), ", "))
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
+
+func (c *CLI) printSongs(songs []domain.Song) error {
+	if c.output != OutputTable {
+		return c.writeRecords(songRecords(songs))
+	}
+
 	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
 	defer tw.Flush()
 
@@ -34,55 +70,293 @@ func (c *CLI) Search(connector, term string) error {
 	fmt.Fprint(tw, "----------\t----------\t----------")
 	fmt.Fprintln(tw)
 	for _, s := range songs {
-		fmt.Fprintf(tw, "%s.%s\t%s\t%s", connector, s.Id, s.Name, s.Artists)
+		fmt.Fprintf(tw, "%s\t%s\t%s", s.Ref(), s.Name, s.Artists)
 		fmt.Fprintln(tw)
 	}
-
 	return nil
 }
 
-func (c *CLI) Play(input string) error {
-	parts := strings.SplitN(input, ".", 2)
-	if len(parts) != 2 {
-		return errors.Errorf("invalid id %s", input)
+func parseSongRef(input string) (domain.Song, error) {
+	ref, err := domain.ParseSongRef(input)
+	if err != nil {
+		return domain.Song{}, err
 	}
-	cName, id := parts[0], parts[1]
-	player, err := c.app.Play(id, cName)
+	return ref.Song(), nil
+}
+
+type PlayOptions struct {
+	Sleep    domain.SleepTimer
+	From     string // timestamp or bookmark name
+	Loop     string // <A>-<B> where A and B are timestamps or bookmark names
+	Volume   int    // percent, the default volume is used when zero
+	Autoplay bool   // queue songs related to the last one when the queue runs out
+}
+
+// parseSong accepts a song ref or a share url of a song
+func (c *CLI) parseSong(input string) (domain.Song, error) {
+	// the id of a direct song may be an url itself
+	if !strings.Contains(input, "://") || strings.HasPrefix(input, domain.DirectConnector+":") {
+		return parseSongRef(input)
+	}
+
+	link, err := c.app.ResolveLink(input)
 	if err != nil {
-		return errors.Errorf("error getting player: %s", err)
+		return domain.Song{}, err
+	}
+	if link.Kind != domain.LinkSong {
+		return domain.Song{}, errors.Errorf("url %s is not a song", input)
 	}
+	return domain.Song{Id: link.Id, Connector: link.Connector}, nil
+}
 
-	song := player.Report().Song
-	fmt.Fprintf(c.out, "Playing %s (%s), duration %s", song.Name, song.Artists, song.Duration)
-	fmt.Fprintln(c.out)
+func (c *CLI) Play(input string, opts PlayOptions) error {
+	song, err := c.parseSong(input)
+	if err != nil {
+		return err
+	}
+	return c.playSongs([]domain.Song{song}, opts)
+}
 
-	go func() {
-		isLoading := false
+// playSongs plays songs in a new queue, the start position and the loop apply to the first song
+func (c *CLI) playSongs(songs []domain.Song, opts PlayOptions) error {
+	groups := make([]domain.SongGroup, len(songs))
+	for idx, s := range songs {
+		groups[idx] = domain.SongGroup{Song: s}
+	}
+	return c.playGroups(groups, opts)
+}
 
+// playGroups is playSongs for the songs found along with their alternatives, which are played when they fail
+func (c *CLI) playGroups(groups []domain.SongGroup, opts PlayOptions) error {
+	if len(groups) == 0 {
+		return errors.New("there is no song to play")
+	}
+
+	queue := domain.NewQueue(c.app)
+	queue.AddGroups(groups...)
+	if opts.From != "" {
+		pos, err := c.resolvePos(groups[0].Song, opts.From)
+		if err != nil {
+			return err
+		}
+		queue.Seek(pos)
+	}
+	if opts.Loop != "" {
+		loop, err := c.resolveLoop(groups[0].Song, opts.Loop)
+		if err != nil {
+			return err
+		}
+		queue.SetLoop(loop)
+	}
+
+	return c.playQueue(queue, 0, opts)
+}
+
+// Resume plays the queue of the last session from where it was left
+func (c *CLI) Resume(opts PlayOptions) error {
+	store := c.app.Storage().Session
+	session, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if !session.IsResumable() {
+		return errors.New("there is no session to resume")
+	}
+
+	queue := domain.NewQueue(c.app)
+	queue.Restore(session)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
 		for {
 			select {
-			case <-time.After(c.reportInterval):
-				report := player.Report()
-				switch report.State {
-				case domain.StateNotInitialized:
-					fallthrough
-				case domain.StateLoading:
-					if !isLoading {
-						isLoading = true
-						fmt.Fprintln(c.out, "Loading...")
-					}
-				case domain.StatePlaying:
-					fmt.Fprintf(c.out, "Playing: %s/%s", report.Pos, report.Len)
-					fmt.Fprintln(c.out)
-				case domain.StatePaused:
-					fmt.Fprintf(c.out, "Paused: %s/%s", report.Pos, report.Len)
-					fmt.Fprintln(c.out)
-				default:
+			case <-done:
+				return
+			case <-time.After(sessionSaveInterval):
+				store.Save(queue.Snapshot())
+			}
+		}
+	}()
+
+	err = c.playQueue(queue, session.Index, opts)
+	if saveErr := store.Save(queue.Snapshot()); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+func (c *CLI) playQueue(queue domain.Queue, index int, opts PlayOptions) error {
+	queue.Sleep(opts.Sleep)
+	queue.SetAutoplay(opts.Autoplay)
+	if opts.Volume != 0 {
+		queue.SetVolume(opts.Volume)
+	}
+	if err := queue.Play(index); err != nil {
+		return err
+	}
+
+	if keys, out, ok := c.terminal(); ok {
+		return c.playInteractive(queue, keys, out)
+	}
+	go c.watch(queue)
+
+	return queue.Wait()
+}
+
+func (c *CLI) watch(queue domain.Queue) {
+	playing := -1
+	var source domain.SongRef
+	isLoading := false
+
+	for {
+		select {
+		case <-time.After(c.reportInterval):
+			report := queue.Report()
+			if report.Playing < 0 {
+				continue
+			}
+
+			// the source changes without the index when the song is played from another connector
+			if report.Playing != playing || report.Player.Song.Ref() != source {
+				playing, source, isLoading = report.Playing, report.Player.Song.Ref(), false
+				song := report.Player.Song
+				fmt.Fprintf(c.out, "Playing %s (%s), duration %s", song.Name, song.Artists, song.Duration)
+				if report.Alternative {
+					fmt.Fprintf(c.out, ", from %s as the queued source failed", source)
+				}
+				fmt.Fprintln(c.out)
+			}
+
+			switch report.Player.State {
+			case domain.StateNotInitialized:
+				fallthrough
+			case domain.StateLoading:
+				if !isLoading {
+					isLoading = true
+					fmt.Fprintln(c.out, "Loading...")
+				}
+			case domain.StatePlaying:
+				fmt.Fprintf(c.out, "Playing: %s/%s%s%s", report.Player.Pos, report.Player.Len, formatLoop(report.Player.Loop), formatSleep(report.Sleep))
+				fmt.Fprintln(c.out)
+			case domain.StatePaused:
+				fmt.Fprintf(c.out, "Paused: %s/%s%s%s", report.Player.Pos, report.Player.Len, formatLoop(report.Player.Loop), formatSleep(report.Sleep))
+				fmt.Fprintln(c.out)
+			default:
+				// autoplay queues more songs once the last one is done
+				if report.Playing >= report.Len-1 && !report.Autoplay {
 					return
 				}
 			}
 		}
-	}()
+	}
+}
+
+func formatLoop(loop domain.Segment) string {
+	if loop.IsEmpty() {
+		return ""
+	}
+	return fmt.Sprintf(" (loop %s-%s)", utils.FormatTimestamp(loop.Start), utils.FormatTimestamp(loop.End))
+}
+
+func formatSleep(sleep domain.SleepStatus) string {
+	switch sleep.Mode {
+	case domain.SleepAfterTime:
+		return fmt.Sprintf(" (sleep in %s)", sleep.Remaining)
+	case domain.SleepAfterSong:
+		return " (stop after this song)"
+	default:
+		return ""
+	}
+}
+
+// resolvePos parses input as a timestamp, falling back to a bookmark of song
+func (c *CLI) resolvePos(song domain.Song, input string) (time.Duration, error) {
+	if pos, err := utils.ParseTimestamp(input); err == nil {
+		return pos, nil
+	}
+
+	bookmark, err := c.app.Storage().Bookmarks.Get(song, input)
+	if err != nil {
+		return 0, errors.Errorf("%s is neither a timestamp nor a bookmark", input)
+	}
+	return bookmark.Pos, nil
+}
+
+func (c *CLI) resolveLoop(song domain.Song, input string) (domain.Segment, error) {
+	parts := strings.SplitN(input, "-", 2)
+	if len(parts) != 2 {
+		return domain.Segment{}, errors.Errorf("invalid loop %s, expected <A>-<B>", input)
+	}
+
+	start, err := c.resolvePos(song, parts[0])
+	if err != nil {
+		return domain.Segment{}, err
+	}
+	end, err := c.resolvePos(song, parts[1])
+	if err != nil {
+		return domain.Segment{}, err
+	}
+
+	loop := domain.Segment{Start: start, End: end}
+	if loop.IsEmpty() {
+		return domain.Segment{}, errors.Errorf("invalid loop %s, B must be after A", input)
+	}
+	return loop, nil
+}
+
+func (c *CLI) Bookmarks(input string) error {
+	song, err := parseSongRef(input)
+	if err != nil {
+		return err
+	}
+
+	bookmarks, err := c.app.Storage().Bookmarks.List(song)
+	if err != nil {
+		return err
+	}
+	if c.output != OutputTable {
+		records := make([]bookmarkRecord, len(bookmarks))
+		for idx, b := range bookmarks {
+			records[idx] = bookmarkRecord{Name: b.Name, Pos: b.Pos.Seconds()}
+		}
+		return c.writeRecords(records)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "Tên\tVị trí")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------")
+	fmt.Fprintln(tw)
+	for _, b := range bookmarks {
+		fmt.Fprintf(tw, "%s\t%s", b.Name, utils.FormatTimestamp(b.Pos))
+		fmt.Fprintln(tw)
+	}
+
+	return nil
+}
+
+func (c *CLI) AddBookmark(input, name, pos string) error {
+	song, err := parseSongRef(input)
+	if err != nil {
+		return err
+	}
+
+	d, err := utils.ParseTimestamp(pos)
+	if err != nil {
+		return err
+	}
+
+	return c.app.Storage().Bookmarks.Save(song, domain.Bookmark{Name: name, Pos: d})
+}
+
+func (c *CLI) RemoveBookmark(input, name string) error {
+	song, err := parseSongRef(input)
+	if err != nil {
+		return err
+	}
 
-	return player.Start()
+	return c.app.Storage().Bookmarks.Delete(song, name)
 }
@@ -2,10 +2,15 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io.github.binatory/budich-cli/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -27,16 +32,74 @@ func (m *mockApp) Search(cName, term string) ([]domain.Song, error) {
 	return called.Get(0).([]domain.Song), called.Error(1)
 }
 
-func (m *mockApp) Play(id, connectorName string) (domain.Player, error) {
-	called := m.Called(id, connectorName)
+func (m *mockApp) SearchAll(term string) ([]domain.Song, error) {
+	called := m.Called(term)
+	return called.Get(0).([]domain.Song), called.Error(1)
+}
+
+func (m *mockApp) Song(ref domain.SongRef) (domain.StreamableSong, error) {
+	called := m.Called(ref)
+	return called.Get(0).(domain.StreamableSong), called.Error(1)
+}
+
+func (m *mockApp) SongDetails(ref domain.SongRef) (domain.Song, error) {
+	called := m.Called(ref)
+	return called.Get(0).(domain.Song), called.Error(1)
+}
+
+func (m *mockApp) Play(ref domain.SongRef) (domain.Player, error) {
+	called := m.Called(ref)
 	return called.Get(0).(domain.Player), called.Error(1)
 }
 
+func (m *mockApp) Alternatives(song domain.Song) ([]domain.Song, error) {
+	called := m.Called(song)
+	return called.Get(0).([]domain.Song), called.Error(1)
+}
+
+func (m *mockApp) Recommend(song domain.Song) ([]domain.Song, error) {
+	called := m.Called(song)
+	return called.Get(0).([]domain.Song), called.Error(1)
+}
+
+func (m *mockApp) Lyrics(ref domain.SongRef) (domain.Lyrics, error) {
+	called := m.Called(ref)
+	return called.Get(0).(domain.Lyrics), called.Error(1)
+}
+
+func (m *mockApp) Playlist(source domain.PlaylistSource) (domain.Playlist, error) {
+	called := m.Called(source)
+	return called.Get(0).(domain.Playlist), called.Error(1)
+}
+
+func (m *mockApp) Chart(connectorName string, kind domain.ChartKind, region string) (domain.Chart, error) {
+	called := m.Called(connectorName, kind, region)
+	return called.Get(0).(domain.Chart), called.Error(1)
+}
+
+func (m *mockApp) Suggest(prefix string) ([]string, error) {
+	called := m.Called(prefix)
+	return called.Get(0).([]string), called.Error(1)
+}
+
+func (m *mockApp) ResolveLink(raw string) (domain.Link, error) {
+	called := m.Called(raw)
+	return called.Get(0).(domain.Link), called.Error(1)
+}
+
 func (m *mockApp) CheckForUpdate() (domain.UpdateStatus, error) {
 	called := m.Called()
 	return called.Get(0).(domain.UpdateStatus), called.Error(1)
 }
 
+func (m *mockApp) Storage() domain.Storage {
+	return m.Called().Get(0).(domain.Storage)
+}
+
+func (m *mockApp) Preferences() domain.Preferences {
+	return m.Called().Get(0).(domain.Preferences)
+}
+
 type mockPlayer struct {
 	mock.Mock
 }
@@ -53,6 +116,22 @@ func (p *mockPlayer) Stop() {
 	p.Called()
 }
 
+func (p *mockPlayer) FadeOut(d time.Duration) {
+	p.Called(d)
+}
+
+func (p *mockPlayer) Seek(pos time.Duration) error {
+	return p.Called(pos).Error(0)
+}
+
+func (p *mockPlayer) SetLoop(loop domain.Segment) error {
+	return p.Called(loop).Error(0)
+}
+
+func (p *mockPlayer) SetVolume(percent int) {
+	p.Called(percent)
+}
+
 func (p *mockPlayer) Report() domain.PlayerStatus {
 	return p.Called().Get(0).(domain.PlayerStatus)
 }
@@ -73,7 +152,14 @@ func TestCLI_Search_should_return_the_underlying_error(t *testing.T) {
 func TestCLI_Search_should_output_formatted_result(t *testing.T) {
 	var out bytes.Buffer
 	ma := &mockApp{}
-	ma.On("Search", "toto", "tata").Return([]domain.Song{
+	ma.On("Search", "toto", "tata1").Return([]domain.Song{
+		{
+			Id:        "id2",
+			Name:      "tata2",
+			Artists:   "artist2",
+			Duration:  456,
+			Connector: "toto",
+		},
 		{
 			Id:        "id1",
 			Name:      "tata1",
@@ -82,22 +168,22 @@ func TestCLI_Search_should_output_formatted_result(t *testing.T) {
 			Connector: "toto",
 		},
 		{
-			Id:        "id2",
-			Name:      "tata2",
-			Artists:   "artist2",
-			Duration:  456,
-			Connector: "toto",
+			Id:        "id3",
+			Name:      "tata1",
+			Artists:   "artist1",
+			Duration:  123,
+			Connector: "titi",
 		},
 	}, nil)
 
 	cli := New(&out, ma)
-	got := cli.Search("toto", "tata")
+	got := cli.Search("toto", "tata1")
 	require.NoError(t, got)
 
-	require.Equal(t, `Id             Bài hát        Ca sĩ
-----------     ----------     ----------
-toto.id1       tata1          artist1
-toto.id2       tata2          artist2
+	require.Equal(t, `Id             Bài hát        Ca sĩ          Nguồn khác
+----------     ----------     ----------     ----------
+toto:id1       tata1          artist1        titi:id3
+toto:id2       tata2          artist2        
 `, out.String())
 
 	ma.AssertExpectations(t)
@@ -125,11 +211,11 @@ func TestCLI_Play(t *testing.T) {
 	mp.On("Start").Return(errors.New("error start")).After(time.Second)
 
 	ma := &mockApp{}
-	ma.On("Play", "playme", "toto").Return(mp, nil)
+	ma.On("Play", domain.SongRef{Connector: "toto", Id: "playme"}).Return(mp, nil)
 
 	cli := New(&out, ma)
 	cli.reportInterval = 150 * time.Millisecond
-	got := cli.Play("toto.playme")
+	got := cli.Play("toto:playme", PlayOptions{})
 	require.EqualError(t, got, "error start")
 
 	require.Equal(t, `Playing My Song (Artist1, Artist2), duration 2m30s
@@ -149,8 +235,8 @@ func TestCLI_Play_on_error(t *testing.T) {
 		setup func(ma *mockApp)
 	}{
 		{"invalid input", "invalid", nil},
-		{"player.Play error", "valid.input", func(ma *mockApp) {
-			ma.On("Play", mock.Anything, mock.Anything).Return(&mockPlayer{}, errors.New("unexpected"))
+		{"player.Play error", "valid:input", func(ma *mockApp) {
+			ma.On("Play", mock.Anything).Return(&mockPlayer{}, errors.New("unexpected"))
 		}},
 	}
 	for _, tt := range tests {
@@ -161,10 +247,723 @@ func TestCLI_Play_on_error(t *testing.T) {
 				tt.setup(ma)
 			}
 			c := New(&out, ma)
-			err := c.Play(tt.input)
+			err := c.Play(tt.input, PlayOptions{})
 			require.Error(t, err)
 			require.Empty(t, out.String())
 			ma.AssertExpectations(t)
 		})
 	}
 }
+
+func TestCLI_Bookmarks(t *testing.T) {
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(domain.NewStorage(t.TempDir()))
+
+	cli := New(&out, ma)
+	require.NoError(t, cli.AddBookmark("toto:id1", "chorus", "1:05"))
+	require.NoError(t, cli.AddBookmark("toto:id1", "intro", "5s"))
+	require.Error(t, cli.AddBookmark("toto:id1", "outro", "later"))
+	require.NoError(t, cli.Bookmarks("toto:id1"))
+	require.Equal(t, `Tên            Vị trí
+----------     ----------
+intro          0:05
+chorus         1:05
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cli.RemoveBookmark("toto:id1", "intro"))
+	require.Error(t, cli.RemoveBookmark("toto:id1", "intro"))
+	require.NoError(t, cli.Bookmarks("toto:id1"))
+	require.Equal(t, `Tên            Vị trí
+----------     ----------
+chorus         1:05
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cli.SetOutput("jsonl", ""))
+	require.NoError(t, cli.Bookmarks("toto:id1"))
+	require.Equal(t, `{"name":"chorus","pos":65}
+`, out.String())
+}
+
+func TestCLI_Play_with_invalid_loop(t *testing.T) {
+	tests := []struct {
+		name string
+		loop string
+	}{
+		{"missing B", "1:00"},
+		{"B before A", "1:00-0:30"},
+		{"unknown bookmark", "chorus-2:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ma := &mockApp{}
+			ma.On("Storage").Return(domain.NewStorage(t.TempDir())).Maybe()
+
+			c := New(&out, ma)
+			require.Error(t, c.Play("toto:id1", PlayOptions{Loop: tt.loop}))
+			require.Empty(t, out.String())
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCLI_Playlists(t *testing.T) {
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(domain.NewStorage(t.TempDir()))
+	ma.On("SongDetails", domain.SongRef{Connector: "toto", Id: "id1"}).Return(domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"}, nil)
+	ma.On("SongDetails", domain.SongRef{Connector: "toto", Id: "id2"}).Return(domain.Song{Id: "id2", Name: "tata2", Artists: "artist2", Connector: "toto"}, nil)
+
+	cli := New(&out, ma)
+	require.NoError(t, cli.CreatePlaylist("morning"))
+	require.NoError(t, cli.CreatePlaylist("evening"))
+	require.NoError(t, cli.AddToPlaylist("morning", "toto:id1", "toto:id2"))
+	require.Error(t, cli.AddToPlaylist("night", "toto:id1"))
+	require.NoError(t, cli.Playlists(""))
+	require.Equal(t, `Tên            Số bài hát
+----------     ----------
+evening        0
+morning        2
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cli.RemoveFromPlaylist("morning", "toto:id1"))
+	require.NoError(t, cli.Playlists("morning"))
+	require.Equal(t, `Id             Bài hát        Ca sĩ
+----------     ----------     ----------
+toto:id2       tata2          artist2
+`, out.String())
+
+	require.NoError(t, cli.DeletePlaylist("evening"))
+	require.Error(t, cli.PlayPlaylist("evening", PlayOptions{}))
+	ma.AssertExpectations(t)
+}
+
+func TestCLI_Favorites(t *testing.T) {
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(domain.NewStorage(t.TempDir()))
+	ma.On("SongDetails", domain.SongRef{Connector: "toto", Id: "id1"}).Return(domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"}, nil)
+	ma.On("SongDetails", domain.SongRef{Connector: "toto", Id: "id3"}).Return(domain.Song{}, errors.New("not found"))
+
+	cli := New(&out, ma)
+	require.Error(t, cli.PlayFavorites(PlayOptions{}))
+	require.NoError(t, cli.AddFavorite("toto:id1"))
+	require.Error(t, cli.AddFavorite("toto:id3"))
+	require.NoError(t, cli.Favorites())
+	require.Equal(t, `Id             Bài hát        Ca sĩ
+----------     ----------     ----------
+toto:id1       tata1          artist1
+`, out.String())
+
+	require.NoError(t, cli.RemoveFavorite("toto:id1"))
+	require.Error(t, cli.RemoveFavorite("toto:id1"))
+	ma.AssertExpectations(t)
+}
+
+func TestCLI_Stats(t *testing.T) {
+	storage := domain.NewStorage(t.TempDir())
+	start := time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC)
+	for i, e := range []domain.HistoryEntry{
+		{Song: domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"}, Listened: time.Minute},
+		{Song: domain.Song{Id: "id2", Name: "tata2", Artists: "artist1, artist2", Connector: "toto"}, Listened: 30 * time.Second, Skipped: true},
+		{Song: domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"}, Listened: 2 * time.Minute},
+	} {
+		e.StartedAt = start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, storage.History.Record(e))
+	}
+
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(storage)
+
+	cli := New(&out, ma)
+	require.Error(t, cli.Stats(StatsOptions{Since: "last week"}))
+	require.NoError(t, cli.Stats(StatsOptions{Since: "2021-06-01", Until: "2021-06-02", Top: 1}))
+	require.Equal(t, `Tổng: 3 lượt nghe, 3:30
+
+Bài hát        Lượt nghe      Đã nghe
+----------     ----------     ----------
+tata1          2              3:00
+
+Ca sĩ          Lượt nghe      Đã nghe
+----------     ----------     ----------
+artist1        3              3:30
+
+Nguồn          Lượt nghe      Đã nghe
+----------     ----------     ----------
+toto           3              3:30
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cli.SetOutput("csv", ""))
+	require.NoError(t, cli.Stats(StatsOptions{Since: "2021-06-01", Until: "2021-06-02", Top: 1}))
+	require.Equal(t, `kind,id,name,plays,listened
+total,,,3,210
+song,toto:id1,tata1,2,180
+artist,,artist1,3,210
+connector,,toto,3,210
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, cli.SetOutput("json", ""))
+	require.NoError(t, cli.Stats(StatsOptions{Since: "2021-06-02"}))
+	require.JSONEq(t, `[{"kind":"total","id":"","name":"","plays":0,"listened":0}]`, out.String())
+	require.NoError(t, cli.SetOutput("", ""))
+
+	out.Reset()
+	require.NoError(t, cli.History(HistoryOptions{Skipped: true}))
+	require.Contains(t, out.String(), "toto:id2")
+	require.NotContains(t, out.String(), "toto:id1")
+}
+
+func TestCLI_ExportPlaylist_ImportPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(domain.NewStorage(dir))
+	ma.On("ConnectorNames").Return([]string{"toto"})
+
+	library := domain.NewStorage(dir).Library
+	require.NoError(t, library.CreatePlaylist("morning"))
+	require.NoError(t, library.AddToPlaylist("morning",
+		domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Duration: 90 * time.Second, Connector: "toto"},
+		domain.NewDirectSong("/music/local.mp3").Song,
+		domain.NewDirectSong("/music/my song.mp3").Song,
+	))
+
+	cli := New(&out, ma)
+	require.NoError(t, cli.ExportPlaylist("morning", "-", ExportOptions{Format: "m3u8"}))
+	require.Equal(t, `#EXTM3U
+#EXTINF:90,artist1 - tata1
+toto:id1
+#EXTINF:-1,local.mp3
+/music/local.mp3
+#EXTINF:-1,my song.mp3
+/music/my song.mp3
+`, out.String())
+	require.Error(t, cli.ExportPlaylist("morning", "-", ExportOptions{}))
+
+	out.Reset()
+	require.NoError(t, cli.ExportPlaylist("morning", "-", ExportOptions{Format: "xspf"}))
+	require.Contains(t, out.String(), "<location>file:///music/local.mp3</location>")
+	require.Contains(t, out.String(), "<location>file:///music/my%20song.mp3</location>")
+
+	path := dir + "/morning.xspf"
+	require.NoError(t, cli.ExportPlaylist("morning", path, ExportOptions{}))
+
+	out.Reset()
+	require.NoError(t, cli.ImportPlaylist(path, ImportOptions{Save: "copy", NoPlay: true}))
+	require.Equal(t, `Id                         Bài hát         Ca sĩ
+----------                 ----------      ----------
+toto:id1                   tata1           artist1
+url:/music/local.mp3       local.mp3       
+url:/music/my song.mp3     my song.mp3     
+`, out.String())
+
+	copied, err := library.Playlist("copy")
+	require.NoError(t, err)
+	require.Len(t, copied.Songs, 3)
+	require.Equal(t, "artist1", copied.Songs[0].Artists)
+	require.Equal(t, 90*time.Second, copied.Songs[0].Duration)
+}
+
+func TestCLI_ImportPlaylist_remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\ntoto:id1\nsong.mp3\nfile:///etc/passwd\n/etc/passwd\n")
+	}))
+	defer server.Close()
+
+	var out, errOut bytes.Buffer
+	ma := &mockApp{}
+	ma.On("ConnectorNames").Return([]string{"toto"})
+	ma.On("Preferences").Return(domain.Preferences{HttpTimeout: time.Second})
+
+	cli := New(&out, ma)
+	cli.errOut = &errOut
+	require.NoError(t, cli.SetOutput("template", "{{.Ref}}"))
+	require.NoError(t, cli.ImportPlaylist(server.URL+"/list.m3u8", ImportOptions{NoPlay: true}))
+	require.Equal(t, "entry 3 skipped: location file:///etc/passwd of a remote playlist is not an http(s) url\n", errOut.String())
+	require.Equal(t, `toto:id1
+url:`+server.URL+`/song.mp3
+url:`+server.URL+`/etc/passwd
+`, out.String())
+}
+
+func Test_resolveLocation(t *testing.T) {
+	tests := []struct {
+		base, location, want string
+		wantErr              bool
+	}{
+		{"/tmp/list.m3u8", "song.mp3", "/tmp/song.mp3", false},
+		{"/tmp/list.m3u8", "/music/song.mp3", "/music/song.mp3", false},
+		{"/tmp/list.m3u8", "file:///music/my%20song.mp3", "/music/my song.mp3", false},
+		{"/tmp/list.m3u8", "file:", "", true},
+		{"/tmp/list.m3u8", "https://example.com/song.mp3", "https://example.com/song.mp3", false},
+		{"https://example.com/lists/list.m3u8", "song.mp3", "https://example.com/lists/song.mp3", false},
+		{"https://example.com/lists/list.m3u8", "/song.mp3", "https://example.com/song.mp3", false},
+		{"https://example.com/lists/list.m3u8", "http://example.org/song.mp3", "http://example.org/song.mp3", false},
+		{"https://example.com/lists/list.m3u8", "file:///etc/passwd", "", true},
+		{"https://example.com/lists/list.m3u8", "url:/etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			got, err := resolveLocation(tt.base, tt.location)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCLI_FetchPlaylist_SyncPlaylists(t *testing.T) {
+	song1 := domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"}
+	song2 := domain.Song{Id: "id2", Name: "tata2", Artists: "artist2", Connector: "toto"}
+	source := &domain.PlaylistSource{Connector: "toto", Id: "p1"}
+
+	var out bytes.Buffer
+	ma := &mockApp{}
+	ma.On("Storage").Return(domain.NewStorage(t.TempDir()))
+	ma.On("ResolveLink", "https://toto.vn/playlist/p1.html").Return(domain.Link{Kind: domain.LinkPlaylist, Connector: "toto", Id: "p1"}, nil)
+	ma.On("Playlist", domain.PlaylistSource{Connector: "toto", Id: "p1"}).Return(domain.Playlist{Name: "Top", Songs: []domain.Song{song1}, Source: source}, nil).Once()
+	ma.On("Playlist", domain.PlaylistSource{Connector: "toto", Id: "p1"}).Return(domain.Playlist{Name: "Top", Songs: []domain.Song{song2, song1}, Source: source}, nil).Once()
+
+	cli := New(&out, ma)
+	require.Error(t, cli.FetchPlaylist("p1", ""))
+	require.NoError(t, cli.FetchPlaylist("https://toto.vn/playlist/p1.html", "mine"))
+	require.NoError(t, cli.SyncPlaylists(""))
+	require.Equal(t, `Saved playlist mine with 1 songs
+Synced playlist mine, 1 new songs
+`, out.String())
+
+	playlist, err := ma.Storage().Library.Playlist("mine")
+	require.NoError(t, err)
+	require.Equal(t, []domain.Song{song1, song2}, playlist.Songs)
+	ma.AssertExpectations(t)
+}
+
+func TestCLI_parseSong(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		setup   func(ma *mockApp)
+		want    domain.Song
+		wantErr bool
+	}{
+		{"id", "toto:id1", nil, domain.Song{Id: "id1", Connector: "toto"}, false},
+		{"legacy id", "zmp3.id1", nil, domain.Song{Id: "id1", Connector: "zmp3"}, false},
+		{"invalid id", "id1", nil, domain.Song{}, true},
+		{"file name", "song.mp3", nil, domain.Song{}, true},
+		{"song url", "https://toto.vn/bai-hat/id1.html", func(ma *mockApp) {
+			ma.On("ResolveLink", "https://toto.vn/bai-hat/id1.html").Return(domain.Link{Kind: domain.LinkSong, Connector: "toto", Id: "id1"}, nil)
+		}, domain.Song{Id: "id1", Connector: "toto"}, false},
+		{"playlist url", "https://toto.vn/playlist/p1.html", func(ma *mockApp) {
+			ma.On("ResolveLink", "https://toto.vn/playlist/p1.html").Return(domain.Link{Kind: domain.LinkPlaylist, Connector: "toto", Id: "p1"}, nil)
+		}, domain.Song{}, true},
+		{"direct song url", "url:https://example.com/song.mp3", nil, domain.Song{Id: "https://example.com/song.mp3", Connector: domain.DirectConnector}, false},
+		{"unknown url", "https://example.com", func(ma *mockApp) {
+			ma.On("ResolveLink", "https://example.com").Return(domain.Link{}, errors.New("not supported"))
+		}, domain.Song{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ma := &mockApp{}
+			if tt.setup != nil {
+				tt.setup(ma)
+			}
+			got, err := New(&bytes.Buffer{}, ma).parseSong(tt.input)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCLI_PlaySearch(t *testing.T) {
+	songs := []domain.Song{
+		{Id: "id1", Name: "Chạy ngay đi", Artists: "Sơn Tùng M-TP", Connector: "toto"},
+		{Id: "id2", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "toto"},
+	}
+	tests := []struct {
+		name     string
+		opts     SearchPlayOptions
+		in       string
+		setup    func(ma *mockApp)
+		wantPlay string
+		wantErr  string
+	}{
+		{"best match of every connector", SearchPlayOptions{}, "", func(ma *mockApp) {
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return(songs, nil)
+		}, "id2", "unexpected"},
+		{"best match of a connector", SearchPlayOptions{Connector: "toto"}, "", func(ma *mockApp) {
+			ma.On("Search", "toto", "sơn tùng lạc trôi").Return(songs, nil)
+		}, "id2", "unexpected"},
+		{"pick", SearchPlayOptions{Pick: true}, "3\nabc\n2\n", func(ma *mockApp) {
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return(songs, nil)
+		}, "id1", "unexpected"},
+		{"pick among deduplicated songs", SearchPlayOptions{Pick: true}, "3\n2\n", func(ma *mockApp) {
+			duplicate := domain.Song{Id: "id3", Name: "Lac Troi", Artists: "Son Tung MTP", Connector: "titi"}
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return(append(songs, duplicate), nil)
+		}, "id1", "unexpected"},
+		{"alternative of the best match", SearchPlayOptions{}, "", func(ma *mockApp) {
+			duplicate := domain.Song{Id: "id3", Name: "Lac Troi", Artists: "Son Tung MTP", Connector: "titi"}
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return(append(songs, duplicate), nil)
+			ma.On("Play", duplicate.Ref()).Return(&mockPlayer{}, errors.New("vip only"))
+		}, "id2", "vip only"},
+		{"pick nothing", SearchPlayOptions{Pick: true}, "", func(ma *mockApp) {
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return(songs, nil)
+		}, "", "no song has been chosen"},
+		{"no result", SearchPlayOptions{}, "", func(ma *mockApp) {
+			ma.On("SearchAll", "sơn tùng lạc trôi").Return([]domain.Song{}, nil)
+		}, "", "no song found for sơn tùng lạc trôi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ma := &mockApp{}
+			tt.setup(ma)
+			if tt.wantPlay != "" {
+				ma.On("Play", domain.SongRef{Connector: "toto", Id: tt.wantPlay}).Return(&mockPlayer{}, errors.New("unexpected"))
+				ma.On("Alternatives", mock.Anything).Return([]domain.Song{}, nil)
+			}
+
+			c := New(&bytes.Buffer{}, ma)
+			c.in = strings.NewReader(tt.in)
+			require.EqualError(t, c.PlaySearch("sơn tùng lạc trôi", tt.opts), tt.wantErr)
+			ma.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCLI_readBatch(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("ConnectorNames").Return([]string{"toto"})
+	ma.On("ResolveLink", "https://toto.vn/bai-hat/id2.html").Return(domain.Link{Kind: domain.LinkSong, Connector: "toto", Id: "id2"}, nil)
+	ma.On("ResolveLink", "https://example.com").Return(domain.Link{}, errors.New("not supported"))
+
+	var out, errOut bytes.Buffer
+	c := New(&out, ma)
+	c.errOut = &errOut
+	songs, err := c.readBatch(strings.NewReader(`# morning songs
+toto:id1     Chạy ngay đi     Sơn Tùng M-TP
+
+https://toto.vn/bai-hat/id2.html
+{"ref":"toto:id3","id":"id3","name":"Lạc trôi","artists":"Sơn Tùng M-TP","duration":233.5,"connector":"toto","artistIds":["a1"],"albumId":"al1","album":"Lạc trôi (Single)","year":2017}
+titi:id4
+https://example.com
+{"ref":
+url:/music/song.mp3
+url:https://example.com/song.mp3     song.mp3
+{"ref":"url:/music/other.mp3","id":"/music/other.mp3","name":"other.mp3","artists":"","duration":0,"connector":"url"}
+`))
+	require.NoError(t, err)
+	require.Equal(t, []domain.Song{
+		{Id: "id1", Connector: "toto"},
+		{Id: "id2", Connector: "toto"},
+		{Id: "id3", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233500 * time.Millisecond, Connector: "toto",
+			ArtistList: []domain.Artist{{Id: "a1", Name: "Sơn Tùng M-TP"}}, Album: &domain.Album{Id: "al1", Name: "Lạc trôi (Single)"}, Year: 2017},
+		{Id: "/music/song.mp3", Connector: domain.DirectConnector},
+		{Id: "https://example.com/song.mp3", Connector: domain.DirectConnector},
+		{Id: "/music/other.mp3", Name: "other.mp3", Connector: domain.DirectConnector},
+	}, songs)
+	require.Equal(t, `line 6 skipped: connector titi not recognized
+line 7 skipped: not supported
+line 8 skipped: invalid song record: unexpected end of JSON input
+`, errOut.String())
+	require.Empty(t, out.String())
+}
+
+func TestCLI_Complete(t *testing.T) {
+	dir := t.TempDir()
+	storage := domain.NewStorage(dir)
+	ma := &mockApp{}
+	ma.On("ConnectorNames").Return([]string{"nct", "zmp3"})
+	ma.On("Storage").Return(storage)
+
+	now := time.Now()
+	require.NoError(t, storage.History.Record(domain.HistoryEntry{Song: domain.Song{Id: "id1", Name: "Chạy ngay đi", Artists: "Sơn Tùng M-TP", Connector: "zmp3"}, StartedAt: now.Add(-time.Hour)}))
+	require.NoError(t, storage.History.Record(domain.HistoryEntry{Song: domain.Song{Id: "id2", Name: "Lạc trôi", Connector: "zmp3"}, StartedAt: now.Add(-time.Minute)}))
+	require.NoError(t, storage.History.Record(domain.HistoryEntry{Song: domain.NewDirectSong("/music/local.mp3").Song, StartedAt: now}))
+	require.NoError(t, storage.Library.AddFavorite(domain.Song{Id: "id1", Name: "Chạy ngay đi", Artists: "Sơn Tùng M-TP", Connector: "zmp3"}))
+	require.NoError(t, storage.Library.AddFavorite(domain.Song{Id: "id3", Connector: "nct"}))
+	require.NoError(t, storage.Library.CreatePlaylist("morning"))
+	require.NoError(t, storage.Library.CreatePlaylist("night"))
+	require.NoError(t, storage.Library.AddToPlaylist("night", domain.Song{Id: "id4", Name: "Em của ngày hôm qua", Connector: "zmp3"}))
+
+	c := New(&bytes.Buffer{}, ma)
+	require.Equal(t, []string{"zmp3"}, c.CompleteConnectors("z"))
+
+	songs, err := c.CompleteSongs("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"zmp3:id2\tLạc trôi", "zmp3:id1\tChạy ngay đi - Sơn Tùng M-TP", "nct:id3"}, songs)
+	songs, err = c.CompleteSongs("nct:")
+	require.NoError(t, err)
+	require.Equal(t, []string{"nct:id3"}, songs)
+
+	playlists, err := c.CompletePlaylists("n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"night"}, playlists)
+	songs, err = c.CompletePlaylistSongs("night", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"zmp3:id4\tEm của ngày hôm qua"}, songs)
+}
+
+func TestCLI_Config(t *testing.T) {
+	dir := t.TempDir()
+	config := domain.NewConfig(filepath.Join(dir, "config.yaml"), domain.DefaultPreferences(dir), func(key string) string {
+		if key == "BD_SINK" {
+			return "null"
+		}
+		return ""
+	})
+
+	var out bytes.Buffer
+	c := New(&out, &mockApp{})
+	require.NoError(t, c.ConfigSet(config, "httpTimeout", "10s"))
+	require.Empty(t, out.String())
+	require.NoError(t, c.ConfigSet(config, "sink", "wav:/tmp/out.wav"))
+	require.Equal(t, "sink is overridden by the environment variable BD_SINK=null\n", out.String())
+
+	out.Reset()
+	require.NoError(t, c.ConfigGet(config, "httpTimeout"))
+	require.Equal(t, "10s\n", out.String())
+	require.Error(t, c.ConfigGet(config, "toto"))
+
+	out.Reset()
+	require.NoError(t, c.SetOutput("template", "{{.Key}}={{.Value}} ({{.Source}})"))
+	require.NoError(t, c.ConfigList(config))
+	require.Equal(t, `httpTimeout=10s (file)
+logPath=`+filepath.Join(dir, "output.log")+` (default)
+sampleRate=48000 (default)
+sink=null (env)
+defaultConnector=zmp3 (default)
+releasesOnly=true (default)
+`, out.String())
+}
+
+func TestCLI_Lyrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics domain.Lyrics
+		want   string
+	}{
+		{"synced", domain.Lyrics{Synced: true, Lines: []domain.LyricsLine{
+			{Time: 12 * time.Second, Text: "line 1"},
+			{Time: 78 * time.Second, Text: "line 2"},
+		}}, "[0:12] line 1\n[1:18] line 2\n"},
+		{"plain", domain.Lyrics{Lines: []domain.LyricsLine{{Text: "line 1"}, {Text: "line 2"}}}, "line 1\nline 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ma := &mockApp{}
+			ma.On("Lyrics", domain.SongRef{Connector: "toto", Id: "id1"}).Return(tt.lyrics, nil)
+
+			var out bytes.Buffer
+			require.NoError(t, New(&out, ma).Lyrics("toto:id1"))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestCLI_Info(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("SongDetails", domain.SongRef{Connector: "toto", Id: "id1"}).Return(domain.Song{
+		Id:         "id1",
+		Name:       "Lạc trôi",
+		Artists:    "Sơn Tùng M-TP",
+		Duration:   233 * time.Second,
+		Connector:  "toto",
+		ArtistList: []domain.Artist{{Id: "a1", Name: "Sơn Tùng M-TP"}},
+		Album:      &domain.Album{Name: "Single"},
+		Year:       2017,
+		Genres:     []string{"V-Pop"},
+	}, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, New(&out, ma).Info("toto:id1"))
+	require.Equal(t, `Id                    toto:id1
+Bài hát               Lạc trôi
+Ca sĩ                 Sơn Tùng M-TP (a1)
+Album                 Single
+Năm phát hành         2017
+Thể loại              V-Pop
+Thời lượng            3:53
+Nội dung nhạy cảm     Không
+`, out.String())
+}
+
+func TestCLI_Charts(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("Chart", "toto", domain.ChartTop, "kr").Return(domain.Chart{Name: "Top K-Pop", Kind: domain.ChartTop, Region: "kr", Songs: []domain.Song{
+		{Id: "id1", Name: "tata1", Artists: "artist1", Connector: "toto"},
+		{Id: "id2", Name: "tata2", Artists: "artist2", Connector: "toto"},
+	}}, nil)
+
+	var out bytes.Buffer
+	cli := New(&out, ma)
+	require.NoError(t, cli.Charts("toto", ChartOptions{Kind: "top", Region: "KR"}))
+	require.Equal(t, `Top K-Pop
+
+#       Id             Bài hát        Ca sĩ
+---     ----------     ----------     ----------
+1       toto:id1       tata1          artist1
+2       toto:id2       tata2          artist2
+`, out.String())
+
+	require.Error(t, cli.Charts("toto", ChartOptions{Kind: "weekly", Region: "vn"}))
+	require.Error(t, cli.Charts("toto", ChartOptions{Kind: "top", Region: "fr"}))
+	ma.AssertExpectations(t)
+}
+
+func TestCLI_Suggest(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("Suggest", "lac").Return([]string{"lạc trôi", "lạc nhau có phải muôn đời"}, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, New(&out, ma).Suggest("lac"))
+	require.Equal(t, "lạc trôi\nlạc nhau có phải muôn đời\n", out.String())
+	ma.AssertExpectations(t)
+}
+
+func TestCLI_Search_output(t *testing.T) {
+	songs := []domain.Song{
+		{Id: "id1", Name: "tata1", Artists: "artist1, artist2", Duration: 233 * time.Second, Connector: "toto",
+			ArtistList: []domain.Artist{{Id: "a1", Name: "artist1"}, {Id: "a2", Name: "artist2"}},
+			Album:      &domain.Album{Id: "al1", Name: "album1"}, Year: 2017, Genres: []string{"V-Pop", "Pop"},
+			Thumbnail: "https://toto.vn/id1.jpg", Explicit: true},
+		{Id: "id2", Name: "tata2", Artists: "artist3", Duration: 90 * time.Second, Connector: "toto"},
+		{Id: "id3", Name: "tata1", Artists: "artist1, artist2", Duration: 233 * time.Second, Connector: "titi"},
+	}
+	tests := []struct {
+		format   string
+		template string
+		want     string
+	}{
+		{"json", "", `[
+  {
+    "ref": "toto:id1",
+    "id": "id1",
+    "name": "tata1",
+    "artists": "artist1, artist2",
+    "duration": 233,
+    "connector": "toto",
+    "artistIds": [
+      "a1",
+      "a2"
+    ],
+    "albumId": "al1",
+    "album": "album1",
+    "year": 2017,
+    "genres": [
+      "V-Pop",
+      "Pop"
+    ],
+    "thumbnail": "https://toto.vn/id1.jpg",
+    "explicit": true,
+    "alternatives": [
+      "titi:id3"
+    ]
+  },
+  {
+    "ref": "toto:id2",
+    "id": "id2",
+    "name": "tata2",
+    "artists": "artist3",
+    "duration": 90,
+    "connector": "toto"
+  }
+]
+`},
+		{"jsonl", "", `{"ref":"toto:id1","id":"id1","name":"tata1","artists":"artist1, artist2","duration":233,"connector":"toto","artistIds":["a1","a2"],"albumId":"al1","album":"album1","year":2017,"genres":["V-Pop","Pop"],"thumbnail":"https://toto.vn/id1.jpg","explicit":true,"alternatives":["titi:id3"]}
+{"ref":"toto:id2","id":"id2","name":"tata2","artists":"artist3","duration":90,"connector":"toto"}
+`},
+		{"csv", "", `ref,id,name,artists,duration,connector,artistIds,albumId,album,year,genres,thumbnail,explicit,alternatives
+toto:id1,id1,tata1,"artist1, artist2",233,toto,"a1, a2",al1,album1,2017,"V-Pop, Pop",https://toto.vn/id1.jpg,true,titi:id3
+toto:id2,id2,tata2,artist3,90,toto,,,,,,,,
+`},
+		{"tsv", "", "ref\tid\tname\tartists\tduration\tconnector\tartistIds\talbumId\talbum\tyear\tgenres\tthumbnail\texplicit\talternatives\n" +
+			"toto:id1\tid1\ttata1\tartist1, artist2\t233\ttoto\ta1, a2\tal1\talbum1\t2017\tV-Pop, Pop\thttps://toto.vn/id1.jpg\ttrue\ttiti:id3\n" +
+			"toto:id2\tid2\ttata2\tartist3\t90\ttoto\t\t\t\t\t\t\t\t\n"},
+		{"", "{{.Ref}} {{.Name}}", "toto:id1 tata1\ntoto:id2 tata2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			ma := &mockApp{}
+			ma.On("Search", "toto", "tata").Return(songs, nil)
+
+			var out bytes.Buffer
+			cli := New(&out, ma)
+			require.NoError(t, cli.SetOutput(tt.format, tt.template))
+			require.NoError(t, cli.Search("toto", "tata"))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+
+	cli := New(&bytes.Buffer{}, &mockApp{})
+	require.Error(t, cli.SetOutput("xml", ""))
+	require.Error(t, cli.SetOutput("template", ""))
+	require.Error(t, cli.SetOutput("template", "{{.Name"))
+}
+
+func TestCLI_History_output(t *testing.T) {
+	storage := domain.NewStorage(t.TempDir())
+	require.NoError(t, storage.History.Record(domain.HistoryEntry{
+		Song:      domain.Song{Id: "id1", Name: "tata1", Artists: "artist1", Duration: 233 * time.Second, Connector: "toto"},
+		StartedAt: time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC),
+		Listened:  90 * time.Second,
+		Skipped:   true,
+	}))
+
+	ma := &mockApp{}
+	ma.On("Storage").Return(storage)
+
+	var out bytes.Buffer
+	cli := New(&out, ma)
+	require.NoError(t, cli.SetOutput("csv", ""))
+	require.NoError(t, cli.History(HistoryOptions{}))
+	require.Equal(t, `startedAt,ref,id,name,artists,duration,connector,artistIds,albumId,album,year,genres,thumbnail,explicit,listened,skipped
+2021-06-01T20:00:00Z,toto:id1,id1,tata1,artist1,233,toto,,,,,,,,90,true
+`, out.String())
+}
+
+func Test_decodeKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		want     []playbackCommand
+		wantRest string
+	}{
+		{"pause", " ", []playbackCommand{commandPauseOrResume}, ""},
+		{"arrows", "\x1b[D\x1b[C\x1bOC", []playbackCommand{commandSeekBackward, commandSeekForward, commandSeekForward}, ""},
+		{"volume", "+=-", []playbackCommand{commandVolumeUp, commandVolumeUp, commandVolumeDown}, ""},
+		{"next and quit", "npq\x03", []playbackCommand{commandNext, commandPrev, commandQuit, commandQuit}, ""},
+		{"unknown keys", "x\x1b[A", nil, ""},
+		{"escape alone", "\x1bq", []playbackCommand{commandQuit}, ""},
+		{"cut after escape", "n\x1b", []playbackCommand{commandNext}, "\x1b"},
+		{"cut after bracket", "\x1b[", nil, "\x1b["},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest := decodeKeys([]byte(tt.keys))
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantRest, string(rest))
+		})
+	}
+
+	commands, rest := decodeKeys([]byte("\x1b["))
+	commands, rest = decodeKeys(append(rest, 'C'))
+	require.Equal(t, []playbackCommand{commandSeekForward}, commands)
+	require.Empty(t, rest)
+}
+
+func Test_progressLine(t *testing.T) {
+	report := domain.QueueStatus{
+		Player: domain.PlayerStatus{State: domain.StatePaused, Pos: 83 * time.Second, Len: 233 * time.Second,
+			Loop: domain.Segment{Start: 10 * time.Second, End: 20 * time.Second}},
+		Volume:   80,
+		Autoplay: true,
+	}
+	require.Equal(t, "Paused 1:23/3:53 | Vol 80% (loop 0:10-0:20) (autoplay)", progressLine(report))
+	require.Equal(t, "Paused", truncate(progressLine(report), 6))
+}
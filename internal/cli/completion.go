@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"io.github.binatory/budich-cli/internal/domain"
+	"strings"
+)
+
+// completionHistoryLimit is the number of most recent history entries offered by the song completion
+const completionHistoryLimit = 100
+
+// CompleteConnectors returns the connector names starting with prefix
+func (c *CLI) CompleteConnectors(prefix string) []string {
+	var names []string
+	for _, name := range c.app.ConnectorNames() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CompleteSongs returns the refs starting with prefix of the recently played songs, most recent first, then of the favorites.
+// Every ref is followed by a tab and the song name, which the shells show as its description. The direct songs are left out
+func (c *CLI) CompleteSongs(prefix string) ([]string, error) {
+	storage := c.app.Storage()
+	var songs []domain.Song
+	if storage.History != nil {
+		entries, err := storage.History.List(domain.HistoryFilter{Limit: completionHistoryLimit})
+		if err != nil {
+			return nil, err
+		}
+		for idx := len(entries) - 1; idx >= 0; idx-- {
+			songs = append(songs, entries[idx].Song)
+		}
+	}
+	favorites, err := storage.Library.Favorites()
+	if err != nil {
+		return nil, err
+	}
+	songs = append(songs, favorites...)
+
+	return completeSongRefs(songs, prefix), nil
+}
+
+// CompletePlaylistSongs returns the refs starting with prefix of the songs of a playlist
+func (c *CLI) CompletePlaylistSongs(name, prefix string) ([]string, error) {
+	playlist, err := c.app.Storage().Library.Playlist(name)
+	if err != nil {
+		return nil, err
+	}
+	return completeSongRefs(playlist.Songs, prefix), nil
+}
+
+// CompletePlaylists returns the names of the playlists of the library starting with prefix
+func (c *CLI) CompletePlaylists(prefix string) ([]string, error) {
+	playlists, err := c.app.Storage().Library.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, p := range playlists {
+		if strings.HasPrefix(p.Name, prefix) {
+			names = append(names, p.Name)
+		}
+	}
+	return names, nil
+}
+
+// CompleteConfigKeys returns the keys of the settings starting with prefix, along with their descriptions
+func (c *CLI) CompleteConfigKeys(config domain.Config, prefix string) ([]string, error) {
+	entries, err := config.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Key, prefix) {
+			keys = append(keys, fmt.Sprintf("%s\t%s", e.Key, e.Description))
+		}
+	}
+	return keys, nil
+}
+
+func completeSongRefs(songs []domain.Song, prefix string) []string {
+	var completions []string
+	seen := make(map[string]bool)
+	for _, s := range songs {
+		ref := s.Ref().String()
+		if s.Connector == domain.DirectConnector || seen[ref] || !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		seen[ref] = true
+
+		switch {
+		case s.Name != "" && s.Artists != "":
+			completions = append(completions, fmt.Sprintf("%s\t%s - %s", ref, s.Name, s.Artists))
+		case s.Name != "":
+			completions = append(completions, fmt.Sprintf("%s\t%s", ref, s.Name))
+		default:
+			completions = append(completions, ref)
+		}
+	}
+	return completions
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/domain"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// defaultEditor edits the config file when neither VISUAL nor EDITOR is set
+const defaultEditor = "vi"
+
+type configRecord struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Env    string `json:"env"`
+}
+
+// ConfigList prints every setting with its value and where the value comes from
+func (c *CLI) ConfigList(config domain.Config) error {
+	entries, err := config.List()
+	if err != nil {
+		return err
+	}
+
+	if c.output != OutputTable {
+		records := make([]configRecord, len(entries))
+		for idx, e := range entries {
+			records[idx] = configRecord{Key: e.Key, Value: e.Value, Source: string(e.Source), Env: e.Env}
+		}
+		return c.writeRecords(records)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "Tùy chọn\tGiá trị\tNguồn\tBiến môi trường\tMô tả")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------\t----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s", e.Key, e.Value, e.Source, e.Env, e.Description)
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
+
+func (c *CLI) ConfigGet(config domain.Config, key string) error {
+	entry, err := config.Get(key)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, entry.Value)
+	return nil
+}
+
+// ConfigSet writes a setting to the config file and warns when an environment variable overrides it
+func (c *CLI) ConfigSet(config domain.Config, key, value string) error {
+	if err := config.Set(key, value); err != nil {
+		return err
+	}
+
+	if entry, err := config.Get(key); err == nil && entry.Source == domain.ConfigEnv {
+		fmt.Fprintf(c.out, "%s is overridden by the environment variable %s=%s", key, entry.Env, entry.Value)
+		fmt.Fprintln(c.out)
+	}
+	return nil
+}
+
+// ConfigEdit opens the config file in the editor of the user, then checks it
+func (c *CLI) ConfigEdit(config domain.Config) error {
+	editor := defaultEditor
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			editor = e
+			break
+		}
+	}
+
+	path := config.Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory of %s", path)
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "error running editor %s", editor)
+	}
+
+	_, err := config.Preferences()
+	return errors.Wrap(err, "the config file has been saved with errors")
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"io.github.binatory/budich-cli/internal/domain"
+	"io.github.binatory/budich-cli/internal/utils"
+	"text/tabwriter"
+	"time"
+)
+
+const historyTimeLayout = "2006-01-02 15:04"
+
+type HistoryOptions struct {
+	Since     string // date or duration before now, see utils.ParseTime
+	Until     string
+	Connector string
+	Term      string
+	Skipped   bool
+	Limit     int
+}
+
+type StatsOptions struct {
+	Since string
+	Until string
+	Top   int
+}
+
+// timeRange parses the since and until bounds, an empty bound is left unset
+func timeRange(since, until string) (time.Time, time.Time, error) {
+	var bounds [2]time.Time
+	now := time.Now()
+	for i, s := range []string{since, until} {
+		if s == "" {
+			continue
+		}
+		t, err := utils.ParseTime(s, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		bounds[i] = t
+	}
+	return bounds[0], bounds[1], nil
+}
+
+func (c *CLI) History(opts HistoryOptions) error {
+	since, until, err := timeRange(opts.Since, opts.Until)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.app.Storage().History.List(domain.HistoryFilter{
+		Since:       since,
+		Until:       until,
+		Connector:   opts.Connector,
+		Term:        opts.Term,
+		SkippedOnly: opts.Skipped,
+		Limit:       opts.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	if c.output != OutputTable {
+		records := make([]historyRecord, len(entries))
+		for idx, e := range entries {
+			records[idx] = historyRecord{StartedAt: e.StartedAt, songRecord: newSongRecord(e.Song), Listened: e.Listened.Seconds(), Skipped: e.Skipped}
+		}
+		return c.writeRecords(records)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "Thời gian\tId\tBài hát\tCa sĩ\tĐã nghe\tBỏ qua")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------\t----------\t----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for _, e := range entries {
+		skipped := ""
+		if e.Skipped {
+			skipped = "x"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s", e.StartedAt.Local().Format(historyTimeLayout), e.Song.Ref(), e.Song.Name, e.Song.Artists, utils.FormatTimestamp(e.Listened), skipped)
+		fmt.Fprintln(tw)
+	}
+
+	return nil
+}
+
+func (c *CLI) Stats(opts StatsOptions) error {
+	since, until, err := timeRange(opts.Since, opts.Until)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.app.Storage().History.List(domain.HistoryFilter{Since: since, Until: until})
+	if err != nil {
+		return err
+	}
+	stats := domain.ComputeStats(entries, opts.Top)
+
+	if c.output != OutputTable {
+		records := []statsRecord{{Kind: "total", Plays: stats.Plays, Listened: stats.Listened.Seconds()}}
+		for _, section := range []struct {
+			kind  string
+			items []domain.StatsItem
+		}{
+			{"song", stats.TopSongs},
+			{"artist", stats.TopArtists},
+			{"connector", stats.TopConnectors},
+		} {
+			for _, item := range section.items {
+				records = append(records, statsRecord{Kind: section.kind, Id: item.Id, Name: item.Name, Plays: item.Plays, Listened: item.Listened.Seconds()})
+			}
+		}
+		return c.writeRecords(records)
+	}
+
+	fmt.Fprintf(c.out, "Tổng: %d lượt nghe, %s", stats.Plays, utils.FormatTimestamp(stats.Listened))
+	fmt.Fprintln(c.out)
+	for _, section := range []struct {
+		header string
+		items  []domain.StatsItem
+	}{
+		{"Bài hát", stats.TopSongs},
+		{"Ca sĩ", stats.TopArtists},
+		{"Nguồn", stats.TopConnectors},
+	} {
+		fmt.Fprintln(c.out)
+		c.printStatsItems(section.header, section.items)
+	}
+
+	return nil
+}
+
+func (c *CLI) printStatsItems(header string, items []domain.StatsItem) {
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "%s\tLượt nghe\tĐã nghe", header)
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%d\t%s", item.Name, item.Plays, utils.FormatTimestamp(item.Listened))
+		fmt.Fprintln(tw)
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"io.github.binatory/budich-cli/internal/domain"
+	"io.github.binatory/budich-cli/internal/utils"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Info prints every detail known about a song
+func (c *CLI) Info(input string) error {
+	song, err := c.fetchSong(input)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	for _, field := range songFields(song) {
+		fmt.Fprintf(tw, "%s\t%s", field[0], field[1])
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
+
+// songFields lists the labels and values of the details of a song, skipping the unknown ones
+func songFields(song domain.Song) [][2]string {
+	fields := [][2]string{
+		{"Id", song.Ref().String()},
+		{"Bài hát", song.Name},
+		{"Ca sĩ", formatArtists(song)},
+	}
+	if song.Album != nil {
+		album := song.Album.Name
+		if song.Album.Id != "" {
+			album += " (" + song.Album.Id + ")"
+		}
+		fields = append(fields, [2]string{"Album", album})
+	}
+	if song.Year > 0 {
+		fields = append(fields, [2]string{"Năm phát hành", strconv.Itoa(song.Year)})
+	}
+	if len(song.Genres) > 0 {
+		fields = append(fields, [2]string{"Thể loại", strings.Join(song.Genres, ", ")})
+	}
+	if song.Duration > 0 {
+		fields = append(fields, [2]string{"Thời lượng", utils.FormatTimestamp(song.Duration)})
+	}
+	if song.Thumbnail != "" {
+		fields = append(fields, [2]string{"Ảnh bìa", song.Thumbnail})
+	}
+	explicit := "Không"
+	if song.Explicit {
+		explicit = "Có"
+	}
+	return append(fields, [2]string{"Nội dung nhạy cảm", explicit})
+}
+
+// formatArtists shows the ids of the artists along with their names when they are known
+func formatArtists(song domain.Song) string {
+	if len(song.ArtistList) == 0 {
+		return song.Artists
+	}
+
+	artists := make([]string, len(song.ArtistList))
+	for idx, artist := range song.ArtistList {
+		artists[idx] = artist.Name
+		if artist.Id != "" {
+			artists[idx] += " (" + artist.Id + ")"
+		}
+	}
+	return strings.Join(artists, ", ")
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"golang.org/x/term"
+	"io.github.binatory/budich-cli/internal/domain"
+	"io.github.binatory/budich-cli/internal/utils"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	seekStep   = 10 * time.Second
+	volumeStep = 5
+)
+
+const keysHelp = "space: pause/resume, ←/→: seek, +/-: volume, n/p: next/previous song, q: quit"
+
+type playbackCommand int
+
+const (
+	commandNone playbackCommand = iota
+	commandPauseOrResume
+	commandSeekBackward
+	commandSeekForward
+	commandVolumeUp
+	commandVolumeDown
+	commandNext
+	commandPrev
+	commandQuit
+)
+
+// ttyPath is the terminal of the process, the keys are read from it even when the standard input is piped
+const ttyPath = "/dev/tty"
+
+// terminal returns the file to read the keys from and the output when the output is a terminal,
+// the playback can then be controlled with the keyboard.
+// The terminal is opened apart from the standard input, unless it cannot be, so its reads can be interrupted
+func (c *CLI) terminal() (*os.File, *os.File, bool) {
+	out, ok := c.out.(*os.File)
+	if !ok || !term.IsTerminal(int(out.Fd())) {
+		return nil, nil, false
+	}
+
+	if tty, err := os.Open(ttyPath); err == nil {
+		return tty, out, true
+	}
+	if in, ok := c.in.(*os.File); ok && term.IsTerminal(int(in.Fd())) {
+		return in, out, true
+	}
+	return nil, nil, false
+}
+
+// playInteractive reads the keys pressed while the queue plays and shows its progress on a single line
+func (c *CLI) playInteractive(queue domain.Queue, keys, out *os.File) error {
+	if keys != c.in {
+		defer keys.Close()
+	}
+
+	state, err := makeRaw(keys)
+	if err != nil {
+		// a terminal which cannot be controlled still shows the progress
+		go c.watch(queue)
+		return queue.Wait()
+	}
+	defer restore(keys, state)
+
+	// the line breaks must return the carriage in raw mode
+	fmt.Fprint(out, keysHelp+"\r\n")
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		readKeys(queue, keys)
+	}()
+	// interrupt the pending read so that no key is taken once the terminal is restored,
+	// the reads of a terminal which cannot time out end with the process
+	defer func() {
+		if keys.SetReadDeadline(time.Now()) == nil {
+			<-stopped
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- queue.Wait()
+	}()
+
+	playing := -1
+	var source domain.SongRef
+	for {
+		select {
+		case err := <-done:
+			fmt.Fprint(out, "\r\n")
+			return err
+		case <-time.After(c.reportInterval):
+			report := queue.Report()
+			if report.Playing < 0 {
+				continue
+			}
+
+			if report.Playing != playing || report.Player.Song.Ref() != source {
+				playing, source = report.Playing, report.Player.Song.Ref()
+				song := report.Player.Song
+				fmt.Fprintf(out, "\r\x1b[K[%d/%d] %s (%s), duration %s", report.Playing+1, report.Len, song.Name, song.Artists, utils.FormatTimestamp(song.Duration))
+				if report.Alternative {
+					fmt.Fprintf(out, ", from %s as the queued source failed", source)
+				}
+				fmt.Fprint(out, "\r\n")
+			}
+
+			width, _, err := term.GetSize(int(out.Fd()))
+			if err != nil {
+				width = 80
+			}
+			fmt.Fprint(out, "\r\x1b[K"+truncate(progressLine(report), width-1))
+		}
+	}
+}
+
+// readKeys applies the keys read until the reads fail or time out,
+// the escape sequences cut between two reads are decoded along with the next one
+func readKeys(queue domain.Queue, keys *os.File) {
+	buf := make([]byte, 16)
+	var pending []byte
+	for {
+		n, err := keys.Read(buf)
+		if err != nil {
+			return
+		}
+
+		var commands []playbackCommand
+		commands, pending = decodeKeys(append(pending, buf[:n]...))
+		for _, command := range commands {
+			applyCommand(queue, command)
+		}
+	}
+}
+
+// makeRaw puts the terminal in raw mode, through its raw connection as Fd would make its reads blocking
+func makeRaw(f *os.File) (state *term.State, err error) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	if ctrlErr := conn.Control(func(fd uintptr) {
+		state, err = term.MakeRaw(int(fd))
+	}); ctrlErr != nil {
+		return nil, ctrlErr
+	}
+	return state, err
+}
+
+func restore(f *os.File, state *term.State) {
+	if conn, err := f.SyscallConn(); err == nil {
+		conn.Control(func(fd uintptr) {
+			term.Restore(int(fd), state)
+		})
+	}
+}
+
+// progressLine describes the state of the current song, its position and the playback settings
+func progressLine(report domain.QueueStatus) string {
+	player := report.Player
+	var sb strings.Builder
+	switch player.State {
+	case domain.StatePlaying:
+		sb.WriteString("Playing")
+	case domain.StatePaused:
+		sb.WriteString("Paused")
+	case domain.StateNotInitialized, domain.StateLoading:
+		sb.WriteString("Loading")
+	default:
+		sb.WriteString(string(player.State))
+	}
+	fmt.Fprintf(&sb, " %s/%s | Vol %d%%", utils.FormatTimestamp(player.Pos), utils.FormatTimestamp(player.Len), report.Volume)
+	sb.WriteString(formatLoop(player.Loop))
+	sb.WriteString(formatSleep(report.Sleep))
+	if report.Autoplay {
+		sb.WriteString(" (autoplay)")
+	}
+	return sb.String()
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width])
+}
+
+// decodeKeys translates the keys read in raw mode, the arrows are sent as escape sequences.
+// An escape sequence cut at the end of buf is returned as rest, to be decoded with the keys read next
+func decodeKeys(buf []byte) (commands []playbackCommand, rest []byte) {
+	for i := 0; i < len(buf); i++ {
+		command := commandNone
+		switch buf[i] {
+		case ' ':
+			command = commandPauseOrResume
+		case '+', '=':
+			command = commandVolumeUp
+		case '-', '_':
+			command = commandVolumeDown
+		case 'n', 'N':
+			command = commandNext
+		case 'p', 'P':
+			command = commandPrev
+		case 'q', 'Q', 0x03: // ctrl-c does not send a signal in raw mode
+			command = commandQuit
+		case 0x1b:
+			if i+1 == len(buf) || (i+2 == len(buf) && (buf[i+1] == '[' || buf[i+1] == 'O')) {
+				return commands, buf[i:]
+			}
+			if i+2 < len(buf) && (buf[i+1] == '[' || buf[i+1] == 'O') {
+				switch buf[i+2] {
+				case 'C':
+					command = commandSeekForward
+				case 'D':
+					command = commandSeekBackward
+				}
+				i += 2
+			}
+		}
+		if command != commandNone {
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+func applyCommand(queue domain.Queue, command playbackCommand) {
+	report := queue.Report()
+	switch command {
+	case commandPauseOrResume:
+		queue.PauseOrResume()
+	case commandSeekBackward, commandSeekForward:
+		pos := report.Player.Pos + seekStep
+		if command == commandSeekBackward {
+			pos = report.Player.Pos - seekStep
+		}
+		if pos < 0 {
+			pos = 0
+		}
+		queue.Seek(pos)
+	case commandVolumeUp:
+		queue.SetVolume(report.Volume + volumeStep)
+	case commandVolumeDown:
+		queue.SetVolume(report.Volume - volumeStep)
+	case commandNext:
+		queue.Next()
+	case commandPrev:
+		queue.Prev()
+	case commandQuit:
+		queue.Stop()
+	}
+}
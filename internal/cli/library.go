@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/domain"
+	"strings"
+	"text/tabwriter"
+)
+
+// fetchSong resolves a song id into a song with its metadata, ready to be saved in the library
+func (c *CLI) fetchSong(input string) (domain.Song, error) {
+	song, err := c.parseSong(input)
+	if err != nil {
+		return domain.Song{}, err
+	}
+	return c.app.SongDetails(song.Ref())
+}
+
+func (c *CLI) Favorites() error {
+	songs, err := c.app.Storage().Library.Favorites()
+	if err != nil {
+		return err
+	}
+
+	return c.printSongs(songs)
+}
+
+// SearchLibrary lists the favorites and the songs of the playlists matching query
+func (c *CLI) SearchLibrary(query string) error {
+	songs, err := c.app.Storage().Library.Search(query)
+	if err != nil {
+		return err
+	}
+
+	return c.printSongs(songs)
+}
+
+func (c *CLI) AddFavorite(input string) error {
+	song, err := c.fetchSong(input)
+	if err != nil {
+		return err
+	}
+	return c.app.Storage().Library.AddFavorite(song)
+}
+
+func (c *CLI) RemoveFavorite(input string) error {
+	song, err := parseSongRef(input)
+	if err != nil {
+		return err
+	}
+	return c.app.Storage().Library.RemoveFavorite(song)
+}
+
+func (c *CLI) PlayFavorites(opts PlayOptions) error {
+	songs, err := c.app.Storage().Library.Favorites()
+	if err != nil {
+		return err
+	}
+	return c.playSongs(songs, opts)
+}
+
+// Playlists lists every playlist, or the songs of a playlist when name is given
+func (c *CLI) Playlists(name string) error {
+	library := c.app.Storage().Library
+	if name != "" {
+		playlist, err := library.Playlist(name)
+		if err != nil {
+			return err
+		}
+		return c.printSongs(playlist.Songs)
+	}
+
+	playlists, err := library.Playlists()
+	if err != nil {
+		return err
+	}
+	if c.output != OutputTable {
+		records := make([]playlistRecord, len(playlists))
+		for idx, p := range playlists {
+			records[idx] = playlistRecord{Name: p.Name, Songs: len(p.Songs)}
+		}
+		return c.writeRecords(records)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprint(tw, "Tên\tSố bài hát")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------")
+	fmt.Fprintln(tw)
+	for _, p := range playlists {
+		fmt.Fprintf(tw, "%s\t%d", p.Name, len(p.Songs))
+		fmt.Fprintln(tw)
+	}
+
+	return nil
+}
+
+func (c *CLI) CreatePlaylist(name string) error {
+	return c.app.Storage().Library.CreatePlaylist(name)
+}
+
+func (c *CLI) DeletePlaylist(name string) error {
+	return c.app.Storage().Library.DeletePlaylist(name)
+}
+
+func (c *CLI) AddToPlaylist(name string, inputs ...string) error {
+	songs := make([]domain.Song, 0, len(inputs))
+	for _, input := range inputs {
+		song, err := c.fetchSong(input)
+		if err != nil {
+			return err
+		}
+		songs = append(songs, song)
+	}
+	return c.app.Storage().Library.AddToPlaylist(name, songs...)
+}
+
+func (c *CLI) RemoveFromPlaylist(name, input string) error {
+	song, err := parseSongRef(input)
+	if err != nil {
+		return err
+	}
+	return c.app.Storage().Library.RemoveFromPlaylist(name, song)
+}
+
+func (c *CLI) PlayPlaylist(name string, opts PlayOptions) error {
+	playlist, err := c.app.Storage().Library.Playlist(name)
+	if err != nil {
+		return err
+	}
+	return c.playSongs(playlist.Songs, opts)
+}
+
+// FetchPlaylist copies a playlist of a streaming service, given as <connector>:<id> or as a share url, into the library
+func (c *CLI) FetchPlaylist(input, name string) error {
+	source, err := c.parsePlaylistSource(input)
+	if err != nil {
+		return err
+	}
+
+	playlist, err := c.app.Playlist(source)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		playlist.Name = name
+	}
+	if err := c.app.Storage().Library.ImportPlaylist(playlist); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "Saved playlist %s with %d songs", playlist.Name, len(playlist.Songs))
+	fmt.Fprintln(c.out)
+	return nil
+}
+
+// SyncPlaylists adds the new songs of the streaming service playlists to the library ones,
+// every fetched playlist is synced when name is empty
+func (c *CLI) SyncPlaylists(name string) error {
+	library := c.app.Storage().Library
+
+	var playlists []domain.Playlist
+	if name != "" {
+		playlist, err := library.Playlist(name)
+		if err != nil {
+			return err
+		}
+		if playlist.Source == nil {
+			return errors.Errorf("playlist %s has not been fetched from a streaming service", name)
+		}
+		playlists = append(playlists, playlist)
+	} else {
+		all, err := library.Playlists()
+		if err != nil {
+			return err
+		}
+		for _, playlist := range all {
+			if playlist.Source != nil {
+				playlists = append(playlists, playlist)
+			}
+		}
+	}
+
+	for _, playlist := range playlists {
+		remote, err := c.app.Playlist(*playlist.Source)
+		if err != nil {
+			return err
+		}
+		if err := library.AddToPlaylist(playlist.Name, remote.Songs...); err != nil {
+			return err
+		}
+
+		synced, err := library.Playlist(playlist.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Synced playlist %s, %d new songs", playlist.Name, len(synced.Songs)-len(playlist.Songs))
+		fmt.Fprintln(c.out)
+	}
+	return nil
+}
+
+func (c *CLI) parsePlaylistSource(input string) (domain.PlaylistSource, error) {
+	if strings.Contains(input, "://") {
+		link, err := c.app.ResolveLink(input)
+		if err != nil {
+			return domain.PlaylistSource{}, err
+		}
+		if link.Kind != domain.LinkPlaylist {
+			return domain.PlaylistSource{}, errors.Errorf("url %s is not a playlist", input)
+		}
+		return domain.PlaylistSource{Connector: link.Connector, Id: link.Id}, nil
+	}
+
+	return domain.ParsePlaylistSource(input)
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"io.github.binatory/budich-cli/internal/utils"
+)
+
+// Lyrics prints the lyrics of a song, along with the time of each line when they are synced
+func (c *CLI) Lyrics(input string) error {
+	song, err := c.parseSong(input)
+	if err != nil {
+		return err
+	}
+
+	lyrics, err := c.app.Lyrics(song.Ref())
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lyrics.Lines {
+		if lyrics.Synced {
+			fmt.Fprintf(c.out, "[%s] ", utils.FormatTimestamp(line.Time))
+		}
+		fmt.Fprintln(c.out, line.Text)
+	}
+	return nil
+}
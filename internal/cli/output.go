@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/domain"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type OutputFormat string
+
+const (
+	OutputTable    OutputFormat = "table"
+	OutputJSON     OutputFormat = "json"
+	OutputJSONL    OutputFormat = "jsonl"
+	OutputCSV      OutputFormat = "csv"
+	OutputTSV      OutputFormat = "tsv"
+	OutputTemplate OutputFormat = "template"
+)
+
+var OutputFormats = []OutputFormat{OutputTable, OutputJSON, OutputJSONL, OutputCSV, OutputTSV, OutputTemplate}
+
+// SetOutput chooses how the lists are printed, tmpl is a text/template executed for every item
+func (c *CLI) SetOutput(format, tmpl string) error {
+	if format == "" {
+		format = string(OutputTable)
+		if tmpl != "" {
+			format = string(OutputTemplate)
+		}
+	}
+
+	found := false
+	for _, f := range OutputFormats {
+		found = found || format == string(f)
+	}
+	if !found {
+		return errors.Errorf("output %s not recognized, expected one of %v", format, OutputFormats)
+	}
+	c.output, c.template = OutputFormat(format), nil
+
+	if c.output == OutputTemplate {
+		if tmpl == "" {
+			return errors.New("the template output requires a template")
+		}
+		t, err := template.New("output").Parse(tmpl)
+		if err != nil {
+			return errors.Wrap(err, "error parsing template")
+		}
+		c.template = t
+	}
+	return nil
+}
+
+// songRecord is a song as written by the structured outputs, durations are in seconds.
+// The details left empty by the connector are omitted from the json outputs
+type songRecord struct {
+	Ref       string   `json:"ref"`
+	Id        string   `json:"id"`
+	Name      string   `json:"name"`
+	Artists   string   `json:"artists"`
+	Duration  float64  `json:"duration"`
+	Connector string   `json:"connector"`
+	ArtistIds []string `json:"artistIds,omitempty"` // in the order of Artists
+	AlbumId   string   `json:"albumId,omitempty"`
+	Album     string   `json:"album,omitempty"`
+	Year      int      `json:"year,omitempty"`
+	Genres    []string `json:"genres,omitempty"`
+	Thumbnail string   `json:"thumbnail,omitempty"`
+	Explicit  bool     `json:"explicit,omitempty"`
+}
+
+func newSongRecord(s domain.Song) songRecord {
+	record := songRecord{
+		Ref:       s.Ref().String(),
+		Id:        s.Id,
+		Name:      s.Name,
+		Artists:   s.Artists,
+		Duration:  s.Duration.Seconds(),
+		Connector: s.Connector,
+		Year:      s.Year,
+		Genres:    s.Genres,
+		Thumbnail: s.Thumbnail,
+		Explicit:  s.Explicit,
+	}
+	for _, artist := range s.ArtistList {
+		record.ArtistIds = append(record.ArtistIds, artist.Id)
+	}
+	if s.Album != nil {
+		record.AlbumId, record.Album = s.Album.Id, s.Album.Name
+	}
+	return record
+}
+
+// song reads back a record written by newSongRecord
+func (r songRecord) song() domain.Song {
+	song := domain.Song{
+		Id:        r.Id,
+		Name:      r.Name,
+		Artists:   r.Artists,
+		Duration:  time.Duration(r.Duration * float64(time.Second)),
+		Connector: r.Connector,
+		Year:      r.Year,
+		Genres:    r.Genres,
+		Thumbnail: r.Thumbnail,
+		Explicit:  r.Explicit,
+	}
+	if names := strings.Split(r.Artists, ", "); len(r.ArtistIds) > 0 && len(names) == len(r.ArtistIds) {
+		for idx, id := range r.ArtistIds {
+			song.ArtistList = append(song.ArtistList, domain.Artist{Id: id, Name: names[idx]})
+		}
+	}
+	if r.AlbumId != "" || r.Album != "" {
+		song.Album = &domain.Album{Id: r.AlbumId, Name: r.Album}
+	}
+	return song
+}
+
+func songRecords(songs []domain.Song) []songRecord {
+	records := make([]songRecord, len(songs))
+	for idx, s := range songs {
+		records[idx] = newSongRecord(s)
+	}
+	return records
+}
+
+// searchRecord is a search result along with the refs of its duplicates found on other connectors
+type searchRecord struct {
+	songRecord
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+func searchRecords(groups []domain.SongGroup) []searchRecord {
+	records := make([]searchRecord, len(groups))
+	for idx, g := range groups {
+		records[idx] = searchRecord{songRecord: newSongRecord(g.Song), Alternatives: alternativeRefs(g)}
+	}
+	return records
+}
+
+type historyRecord struct {
+	StartedAt time.Time `json:"startedAt"`
+	songRecord
+	Listened float64 `json:"listened"`
+	Skipped  bool    `json:"skipped"`
+}
+
+type bookmarkRecord struct {
+	Name string  `json:"name"`
+	Pos  float64 `json:"pos"`
+}
+
+// statsRecord is a line of the stats, Kind is total for the overall counts, song, artist or connector otherwise
+type statsRecord struct {
+	Kind     string  `json:"kind"`
+	Id       string  `json:"id"`
+	Name     string  `json:"name"`
+	Plays    int     `json:"plays"`
+	Listened float64 `json:"listened"`
+}
+
+type playlistRecord struct {
+	Name  string `json:"name"`
+	Songs int    `json:"songs"`
+}
+
+// writeRecords writes records, a slice of structs, in the structured output format.
+// The json tags of the struct fields name the columns of the csv and tsv outputs
+func (c *CLI) writeRecords(records interface{}) error {
+	items := reflect.ValueOf(records)
+	switch c.output {
+	case OutputJSON:
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		if items.Len() == 0 {
+			// an empty list rather than null
+			return enc.Encode([]struct{}{})
+		}
+		return enc.Encode(records)
+	case OutputJSONL:
+		enc := json.NewEncoder(c.out)
+		for i := 0; i < items.Len(); i++ {
+			if err := enc.Encode(items.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputCSV, OutputTSV:
+		w := csv.NewWriter(c.out)
+		if c.output == OutputTSV {
+			w.Comma = '\t'
+		}
+		names, _ := recordFields(reflect.New(items.Type().Elem()).Elem())
+		w.Write(names)
+		for i := 0; i < items.Len(); i++ {
+			_, values := recordFields(items.Index(i))
+			w.Write(values)
+		}
+		w.Flush()
+		return w.Error()
+	case OutputTemplate:
+		for i := 0; i < items.Len(); i++ {
+			if err := c.template.Execute(c.out, items.Index(i).Interface()); err != nil {
+				return errors.Wrap(err, "error executing template")
+			}
+			fmt.Fprintln(c.out)
+		}
+		return nil
+	default:
+		return errors.Errorf("output %s is not a structured one", c.output)
+	}
+}
+
+// recordFields lists the json names and the values of the fields of a record, embedded structs included
+func recordFields(record reflect.Value) ([]string, []string) {
+	var names, values []string
+	for i := 0; i < record.NumField(); i++ {
+		field, value := record.Type().Field(i), record.Field(i)
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			n, v := recordFields(value)
+			names, values = append(names, n...), append(values, v...)
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		names = append(names, tag[0])
+		if len(tag) > 1 && tag[1] == "omitempty" && value.IsZero() {
+			// omitted from the json outputs, left empty in the others
+			values = append(values, "")
+			continue
+		}
+		switch v := value.Interface().(type) {
+		case time.Time:
+			values = append(values, v.Format(time.RFC3339))
+		case []string:
+			values = append(values, strings.Join(v, ", "))
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	return names, values
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io.github.binatory/budich-cli/internal/domain"
+	"io.github.binatory/budich-cli/internal/playlistfile"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ExportOptions struct {
+	Format  string // guessed from the file name when empty
+	Resolve bool   // write the streaming urls instead of the song refs, they may expire
+}
+
+type ImportOptions struct {
+	PlayOptions
+	Format string // guessed from the location when empty
+	Save   string // name of the library playlist to copy the songs to
+	NoPlay bool
+}
+
+// ExportPlaylist writes a library playlist to path, or to the output when path is "-"
+func (c *CLI) ExportPlaylist(name, path string, opts ExportOptions) error {
+	format, err := playlistFormat(opts.Format, path)
+	if err != nil {
+		return err
+	}
+
+	playlist, err := c.app.Storage().Library.Playlist(name)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]playlistfile.Entry, 0, len(playlist.Songs))
+	for _, song := range playlist.Songs {
+		entry := playlistfile.Entry{Location: songLocation(song, format), Title: song.Name, Artists: song.Artists, Duration: song.Duration}
+		if opts.Resolve {
+			streamable, err := c.app.Song(song.Ref())
+			if err != nil {
+				return err
+			}
+			entry.Location = streamable.StreamingUrl
+		}
+		entries = append(entries, entry)
+	}
+
+	if path == "-" {
+		return playlistfile.Encode(c.out, format, playlist.Name, entries)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", path)
+	}
+	if err := playlistfile.Encode(f, format, playlist.Name, entries); err != nil {
+		f.Close()
+		return err
+	}
+	return errors.Wrapf(f.Close(), "error writing %s", path)
+}
+
+// ImportPlaylist reads a playlist file from a path or an url then plays its songs
+func (c *CLI) ImportPlaylist(location string, opts ImportOptions) error {
+	format, err := playlistFormat(opts.Format, location)
+	if err != nil {
+		return err
+	}
+
+	r, err := c.openLocation(location)
+	if err != nil {
+		return err
+	}
+	entries, err := playlistfile.Decode(r, format)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	songs := make([]domain.Song, 0, len(entries))
+	for idx, e := range entries {
+		song, err := c.entrySong(location, e)
+		if err != nil {
+			fmt.Fprintf(c.errOut, "entry %d skipped: %s\n", idx+1, err)
+			continue
+		}
+		songs = append(songs, song)
+	}
+
+	if opts.Save != "" {
+		library := c.app.Storage().Library
+		if _, err := library.Playlist(opts.Save); err != nil {
+			if err := library.CreatePlaylist(opts.Save); err != nil {
+				return err
+			}
+		}
+		if err := library.AddToPlaylist(opts.Save, songs...); err != nil {
+			return err
+		}
+	}
+
+	if opts.NoPlay {
+		return c.printSongs(songs)
+	}
+	return c.playSongs(songs, opts.PlayOptions)
+}
+
+func playlistFormat(format, name string) (playlistfile.Format, error) {
+	if format != "" {
+		return playlistfile.ParseFormat(format)
+	}
+	return playlistfile.DetectFormat(name)
+}
+
+func (c *CLI) openLocation(location string) (io.ReadCloser, error) {
+	if !isRemote(location) {
+		f, err := os.Open(location)
+		return f, errors.Wrapf(err, "error opening %s", location)
+	}
+
+	httpClient := &http.Client{Timeout: c.app.Preferences().HttpTimeout}
+	resp, err := httpClient.Get(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching %s", location)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("error fetching %s, got status code %d", location, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// songLocation locates a song in the exported playlists by its ref, or by its url or path for the direct songs.
+// XSPF locations are uris, the local files are written as absolute file uris there
+func songLocation(song domain.Song, format playlistfile.Format) string {
+	if song.Connector != domain.DirectConnector {
+		return song.Ref().String()
+	}
+	if format != playlistfile.FormatXSPF || isRemote(song.Id) || strings.HasPrefix(song.Id, "file:") {
+		return song.Id
+	}
+
+	path, err := filepath.Abs(song.Id)
+	if err != nil {
+		return song.Id
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// entrySong turns a playlist entry into a song of a connector when its location is a song ref,
+// otherwise into a direct song located relatively to the playlist
+func (c *CLI) entrySong(playlistLocation string, e playlistfile.Entry) (domain.Song, error) {
+	var song domain.Song
+	if ref, err := domain.ParseSongRef(e.Location); err == nil && c.isConnector(ref.Connector) {
+		song = ref.Song()
+	} else {
+		location, err := resolveLocation(playlistLocation, e.Location)
+		if err != nil {
+			return domain.Song{}, err
+		}
+		song = domain.NewDirectSong(location).Song
+	}
+
+	if e.Title != "" {
+		song.Name = e.Title
+	}
+	if e.Artists != "" {
+		song.Artists = e.Artists
+	}
+	if e.Duration > 0 {
+		song.Duration = e.Duration
+	}
+	return song, nil
+}
+
+func (c *CLI) isConnector(name string) bool {
+	for _, n := range c.app.ConnectorNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveLocation resolves the location of an entry against the location of its playlist, the local file uris become paths.
+// The entries of a remote playlist must stay remote, it must not make the player read the local files
+func resolveLocation(base, location string) (string, error) {
+	if isRemote(base) {
+		resolved := location
+		if b, err := url.Parse(base); err == nil {
+			if ref, err := url.Parse(location); err == nil {
+				resolved = b.ResolveReference(ref).String()
+			}
+		}
+		if !isRemote(resolved) {
+			return "", errors.Errorf("location %s of a remote playlist is not an http(s) url", location)
+		}
+		return resolved, nil
+	}
+
+	if strings.HasPrefix(location, "file:") {
+		u, err := url.Parse(location)
+		if err != nil || u.Path == "" {
+			return "", errors.Errorf("location %s is not a valid file uri", location)
+		}
+		return filepath.FromSlash(u.Path), nil
+	}
+	if isRemote(location) || filepath.IsAbs(location) {
+		return location, nil
+	}
+	return filepath.Join(filepath.Dir(base), location), nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/domain"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type SearchPlayOptions struct {
+	PlayOptions
+	Connector string // every connector is searched when empty
+	Pick      bool   // let the user choose the song to play
+	All       bool   // queue every result
+}
+
+// Suggest prints the completions of prefix one per line, for the shells to use them
+func (c *CLI) Suggest(prefix string) error {
+	suggestions, err := c.app.Suggest(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range suggestions {
+		fmt.Fprintln(c.out, s)
+	}
+	return nil
+}
+
+// PlaySearch searches songs matching query then plays the best match
+func (c *CLI) PlaySearch(query string, opts SearchPlayOptions) error {
+	var songs []domain.Song
+	var err error
+	if opts.Connector != "" {
+		songs, err = c.app.Search(opts.Connector, query)
+	} else {
+		songs, err = c.app.SearchAll(query)
+	}
+	if err != nil {
+		return err
+	}
+	if len(songs) == 0 {
+		return errors.Errorf("no song found for %s", query)
+	}
+	groups := domain.RankSongs(query, songs)
+
+	switch {
+	case opts.All:
+		return c.playGroups(groups, opts.PlayOptions)
+	case opts.Pick:
+		index, err := c.pick(groups)
+		if err != nil {
+			return err
+		}
+		return c.playGroups(groups[index:index+1], opts.PlayOptions)
+	default:
+		return c.playGroups(groups[:1], opts.PlayOptions)
+	}
+}
+
+// pick lists the songs along with their duplicates then reads the number of the chosen one
+func (c *CLI) pick(songs []domain.SongGroup) (int, error) {
+	tw := tabwriter.NewWriter(c.out, 1, 1, 5, ' ', 0)
+	fmt.Fprint(tw, "#\tId\tBài hát\tCa sĩ\tNguồn khác")
+	fmt.Fprintln(tw)
+	fmt.Fprint(tw, "----------\t----------\t----------\t----------\t----------")
+	fmt.Fprintln(tw)
+	for idx, s := range songs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s", idx+1, s.Ref(), s.Name, s.Artists, strings.Join(alternativeRefs(s), ", "))
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	scanner := bufio.NewScanner(c.in)
+	for {
+		fmt.Fprintf(c.out, "Chọn bài hát [1-%d]: ", len(songs))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, errors.Wrap(err, "error reading choice")
+			}
+			return 0, errors.New("no song has been chosen")
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err == nil && n >= 1 && n <= len(songs) {
+			return n - 1, nil
+		}
+	}
+}
+
+// alternativeRefs returns the refs of the duplicates of a song found on other connectors
+func alternativeRefs(group domain.SongGroup) []string {
+	refs := make([]string, 0, len(group.Alternatives))
+	for _, a := range group.Alternatives {
+		refs = append(refs, a.Ref().String())
+	}
+	return refs
+}
@@ -0,0 +1,72 @@
+// Package coverart downloads the cover arts of the songs and renders them in the terminal
+package coverart
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Cache keeps the downloaded images on disk so that every cover art is downloaded once
+type Cache interface {
+	Get(url string) (image.Image, error)
+}
+
+type cache struct {
+	sync.Mutex
+	dir        string
+	httpClient *http.Client
+}
+
+func NewCache(dir string, httpClient *http.Client) Cache {
+	return &cache{dir: dir, httpClient: httpClient}
+}
+
+func (c *cache) Get(url string) (image.Image, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	sum := sha1.Sum([]byte(url))
+	path := filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		if data, err = c.download(url); err != nil {
+			return nil, err
+		}
+		// a failure to cache must not prevent the image from being shown
+		if os.MkdirAll(c.dir, 0755) == nil {
+			ioutil.WriteFile(path, data, 0644)
+		}
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error reading cached image %s", path)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, errors.Wrapf(err, "error decoding image %s", url)
+}
+
+func (c *cache) download(url string) ([]byte, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error downloading image %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error downloading image %s, got status code %d", url, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, errors.Wrapf(err, "error downloading image %s", url)
+}
@@ -0,0 +1,102 @@
+package coverart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testImage has a red top half and a blue bottom half
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if y >= 2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCache_Get(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, testImage()))
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if r.URL.Path != "/cover.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(encoded.Bytes())
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "covers")
+	c := NewCache(dir, server.Client())
+	for i := 0; i < 2; i++ {
+		img, err := c.Get(server.URL + "/cover.png")
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 4, 4), img.Bounds())
+	}
+	require.Equal(t, 1, downloads)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	_, err = c.Get(server.URL + "/missing.png")
+	require.Error(t, err)
+}
+
+func TestHalfBlocks(t *testing.T) {
+	require.Equal(t, "[#ff0000:#0000ff]▀[#ff0000:#0000ff]▀[-:-]", HalfBlocks(testImage(), 2, 1))
+	require.Equal(t, "[#ff0000:#ff0000]▀[-:-]\n[#0000ff:#0000ff]▀[-:-]", HalfBlocks(testImage(), 1, 2))
+}
+
+func TestSixel(t *testing.T) {
+	got := Sixel(testImage(), 1, 1)
+	require.True(t, strings.HasPrefix(got, "\x1bPq\"1;1;8;16"))
+	require.True(t, strings.HasSuffix(got, "\x1b\\"))
+	// 8 red pixels rows then 8 blue ones, in bands of 6 pixels rows
+	require.Contains(t, got, "#180!8~-#180!8B$#5!8{-#5!8N-")
+}
+
+func TestKitty(t *testing.T) {
+	got, err := Kitty(testImage(), 2, 1)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(got, "\x1b_Ga=T,f=100,q=2,c=2,r=1,m=0;"))
+	require.True(t, strings.HasSuffix(got, "\x1b\\"))
+}
+
+func TestDetectProtocol(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Protocol
+	}{
+		{"kitty", map[string]string{"TERM": "xterm-kitty"}, ProtocolKitty},
+		{"wezterm", map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, ProtocolKitty},
+		{"foot", map[string]string{"TERM": "foot"}, ProtocolSixel},
+		{"xterm", map[string]string{"TERM": "xterm-256color"}, ProtocolHalfBlocks},
+		{"nothing", map[string]string{}, ProtocolHalfBlocks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, DetectProtocol(func(key string) string { return tt.env[key] }))
+		})
+	}
+}
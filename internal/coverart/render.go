@@ -0,0 +1,192 @@
+package coverart
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Protocol is the way images are drawn in a terminal
+type Protocol string
+
+const (
+	// ProtocolHalfBlocks draws two pixels per cell with "▀" and true colors, it works in any terminal
+	ProtocolHalfBlocks Protocol = "halfblocks"
+	ProtocolSixel      Protocol = "sixel"
+	ProtocolKitty      Protocol = "kitty"
+)
+
+// the usual size in pixels of a terminal cell, used to size the sixel images
+const (
+	cellWidth  = 8
+	cellHeight = 16
+)
+
+// DetectProtocol guesses the best protocol supported by the terminal from its environment variables
+func DetectProtocol(getenv func(string) string) Protocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") || program == "WezTerm" || program == "ghostty":
+		return ProtocolKitty
+	case strings.Contains(term, "mlterm") || strings.HasPrefix(term, "foot") || strings.Contains(term, "sixel") ||
+		program == "iTerm.app" || program == "mintty":
+		return ProtocolSixel
+	default:
+		return ProtocolHalfBlocks
+	}
+}
+
+// HalfBlocks renders img in cols x rows cells as a text with tview color tags
+func HalfBlocks(img image.Image, cols, rows int) string {
+	scaled := resize(img, cols, rows*2)
+
+	var sb strings.Builder
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			top, bottom := scaled.RGBAAt(x, 2*y), scaled.RGBAAt(x, 2*y+1)
+			fmt.Fprintf(&sb, "[#%02x%02x%02x:#%02x%02x%02x]▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		sb.WriteString("[-:-]")
+		if y < rows-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// KittyDelete removes every image drawn with the kitty protocol
+const KittyDelete = "\x1b_Ga=d\x1b\\"
+
+// Kitty renders img in cols x rows cells at the cursor position with the kitty graphics protocol
+func Kitty(img image.Image, cols, rows int) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resize(img, cols*cellWidth, rows*cellHeight)); err != nil {
+		return "", err
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// the payload is sent in chunks of at most 4096 bytes, m=1 tells more chunks follow
+	var sb strings.Builder
+	for first := true; len(data) > 0; first = false {
+		chunk := data
+		if len(chunk) > 4096 {
+			chunk = chunk[:4096]
+		}
+		data = data[len(chunk):]
+
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,q=2,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return sb.String(), nil
+}
+
+// Sixel renders img in cols x rows cells at the cursor position with the sixel graphics,
+// its colors are reduced to a palette of 6 levels per channel
+func Sixel(img image.Image, cols, rows int) string {
+	scaled := resize(img, cols*cellWidth, rows*cellHeight)
+	width, height := scaled.Bounds().Dx(), scaled.Bounds().Dy()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\x1bPq\"1;1;%d;%d", width, height)
+	for i := 0; i < 216; i++ {
+		r, g, b := i/36, i/6%6, i%6
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, r*100/5, g*100/5, b*100/5)
+	}
+
+	// each band is 6 pixels high, drawn once per color it contains
+	for top := 0; top < height; top += 6 {
+		bands := make(map[int][]byte)
+		var colors []int
+		for x := 0; x < width; x++ {
+			for dy := 0; dy < 6 && top+dy < height; dy++ {
+				c := paletteIndex(scaled.RGBAAt(x, top+dy))
+				band, found := bands[c]
+				if !found {
+					band = make([]byte, width)
+					bands[c] = band
+					colors = append(colors, c)
+				}
+				band[x] |= 1 << dy
+			}
+		}
+
+		for idx, c := range colors {
+			fmt.Fprintf(&sb, "#%d", c)
+			writeSixelRuns(&sb, bands[c])
+			if idx < len(colors)-1 {
+				sb.WriteByte('$')
+			}
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+// writeSixelRuns writes the sixels of a band, compressing the repeated ones
+func writeSixelRuns(sb *strings.Builder, band []byte) {
+	for x := 0; x < len(band); {
+		n := 1
+		for x+n < len(band) && band[x+n] == band[x] {
+			n++
+		}
+		char := byte(63 + band[x])
+		if n > 3 {
+			fmt.Fprintf(sb, "!%d%c", n, char)
+		} else {
+			sb.WriteString(strings.Repeat(string(char), n))
+		}
+		x += n
+	}
+}
+
+func paletteIndex(c color.RGBA) int {
+	level := func(v uint8) int {
+		return (int(v)*5 + 127) / 255
+	}
+	return level(c.R)*36 + level(c.G)*6 + level(c.B)
+}
+
+// resize scales img to width x height, averaging the source pixels covered by every destination pixel
+func resize(img image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return dst
+	}
+
+	for y := 0; y < height; y++ {
+		y0, y1 := bounds.Min.Y+y*srcHeight/height, bounds.Min.Y+(y+1)*srcHeight/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := bounds.Min.X+x*srcWidth/width, bounds.Min.X+(x+1)*srcWidth/width
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.RGBAModel.Convert(img.At(sx, sy)).(color.RGBA)
+					r, g, b, n = r+uint64(c.R), g+uint64(c.G), b+uint64(c.B), n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 255})
+		}
+	}
+	return dst
+}
@@ -2,45 +2,62 @@ package domain
 
 import (
 	"io.github.binatory/budich-cli/internal/utils"
+	"io.github.binatory/budich-cli/internal/vietnamese"
 	"net/http"
-	"time"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type App interface {
 	Init() error
 	ConnectorNames() []string
 	Search(cName, term string) ([]Song, error)
-	Play(id, connectorName string) (Player, error)
+	SearchAll(term string) ([]Song, error)
+	Suggest(prefix string) ([]string, error)
+	Song(ref SongRef) (StreamableSong, error)
+	SongDetails(ref SongRef) (Song, error)
+	Play(ref SongRef) (Player, error)
+	Alternatives(song Song) ([]Song, error)
+	Recommend(song Song) ([]Song, error)
+	Lyrics(ref SongRef) (Lyrics, error)
+	Playlist(source PlaylistSource) (Playlist, error)
+	Chart(connectorName string, kind ChartKind, region string) (Chart, error)
+	ResolveLink(raw string) (Link, error)
 	CheckForUpdate() (UpdateStatus, error)
+	Storage() Storage
+	Preferences() Preferences
 }
 
 type app struct {
+	prefs          Preferences
 	connectors     map[string]Connector
 	updateNotifier UpdateNotifier
+	sink           Sink
+	storage        Storage
 }
 
-var (
-	defaultHttpClient = &http.Client{Timeout: 30 * time.Second}
-	defaultApp        = NewApp(
-		NewUpdateNotifier(defaultHttpClient, true), // TODO use releasesOnly from user preferences
-		NewConnectorZingMp3(defaultHttpClient),
-		NewConnectorNhacCuaTui(defaultHttpClient),
+func DefaultApp(prefs Preferences, sink Sink, dataDir string) App {
+	httpClient := &http.Client{Timeout: prefs.HttpTimeout}
+	return NewApp(
+		prefs,
+		NewUpdateNotifier(httpClient, prefs.ReleasesOnly),
+		sink,
+		NewStorage(dataDir),
+		NewConnectorZingMp3(httpClient),
+		NewConnectorNhacCuaTui(httpClient),
 	)
-)
-
-func DefaultApp() App {
-	return defaultApp
 }
 
-func NewApp(updateNotifier UpdateNotifier, connectors ...Connector) App {
+func NewApp(prefs Preferences, updateNotifier UpdateNotifier, sink Sink, storage Storage, connectors ...Connector) App {
 	c := make(map[string]Connector, len(connectors))
 	for _, conn := range connectors {
 		c[conn.Name()] = conn
 	}
 
-	return &app{connectors: c, updateNotifier: updateNotifier}
+	return &app{prefs: prefs, connectors: c, updateNotifier: updateNotifier, sink: sink, storage: storage}
 }
 
 func (a *app) Init() error {
@@ -56,29 +73,307 @@ func (a *app) ConnectorNames() []string {
 	return utils.GetMapKeys(a.connectors)
 }
 
+// Search searches with a connector, then with the alternatives of term when nothing is found.
+// The term is recorded to be suggested later
 func (a *app) Search(cName, term string) ([]Song, error) {
+	a.recordSearch(term)
+	return a.search(cName, term)
+}
+
+func (a *app) search(cName, term string) ([]Song, error) {
 	c, foundConnector := a.connectors[cName]
 	if !foundConnector {
 		return nil, errors.Errorf("connector %s not recognized", cName)
 	}
 
-	return c.Search(term)
+	songs, err := c.Search(term)
+	if err != nil || len(songs) > 0 {
+		return songs, err
+	}
+
+	// the term may have been typed without tone marks or with Telex/VNI, which some services do not understand
+	for _, alternative := range vietnamese.Alternatives(term) {
+		if songs, err := c.Search(alternative); err == nil && len(songs) > 0 {
+			return songs, nil
+		}
+	}
+	return songs, nil
+}
+
+// SearchAll searches every connector at once, it fails only when all of them fail
+func (a *app) SearchAll(term string) ([]Song, error) {
+	a.recordSearch(term)
+	return a.searchAll(term)
 }
 
-func (a *app) Play(id, connectorName string) (Player, error) {
+func (a *app) searchAll(term string) ([]Song, error) {
+	names := a.ConnectorNames()
+	results := make([][]Song, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for idx, name := range names {
+		wg.Add(1)
+		go func(idx int, name string) {
+			defer wg.Done()
+			results[idx], errs[idx] = a.search(name, term)
+		}(idx, name)
+	}
+	wg.Wait()
+
+	var songs []Song
+	var lastErr error
+	for idx := range names {
+		if errs[idx] != nil {
+			lastErr = errors.Wrapf(errs[idx], "error searching with connector %s", names[idx])
+			continue
+		}
+		songs = append(songs, results[idx]...)
+	}
+	if songs == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	return songs, nil
+}
+
+// recordSearch keeps term in the search history, a failure must not prevent the search
+func (a *app) recordSearch(term string) {
+	if a.storage.Searches == nil {
+		return
+	}
+	if err := a.storage.Searches.Record(term); err != nil {
+		log.Warn().Msgf("unable to record search %s: %s", term, err)
+	}
+}
+
+// Suggest completes prefix with the matching past searches first, then with the suggestions of the connectors
+func (a *app) Suggest(prefix string) ([]string, error) {
+	var suggestions []string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" || len(suggestions) == suggestionsLimit {
+			return
+		}
+		for _, existing := range suggestions {
+			if strings.EqualFold(existing, s) {
+				return
+			}
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	if a.storage.Searches != nil {
+		terms, err := a.storage.Searches.Terms()
+		if err != nil {
+			return nil, err
+		}
+		for _, term := range terms {
+			if hasPrefix(term, prefix) {
+				add(term)
+			}
+		}
+	}
+	if strings.TrimSpace(prefix) == "" {
+		return suggestions, nil
+	}
+
+	// the connectors suggestions are a bonus, the failing ones are ignored
+	names := a.ConnectorNames()
+	results := make([][]string, len(names))
+	var wg sync.WaitGroup
+	for idx, name := range names {
+		s, ok := a.connectors[name].(Suggester)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, name string, s Suggester) {
+			defer wg.Done()
+			var err error
+			if results[idx], err = s.Suggest(prefix); err != nil {
+				log.Debug().Msgf("unable to get suggestions from connector %s: %s", name, err)
+			}
+		}(idx, name, s)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		for _, s := range result {
+			add(s)
+		}
+	}
+	return suggestions, nil
+}
+
+// Song fetches the details of a song along with its streaming url
+func (a *app) Song(ref SongRef) (StreamableSong, error) {
+	if ref.Connector == DirectConnector {
+		return NewDirectSong(ref.Id), nil
+	}
+
+	c, foundConnector := a.connectors[ref.Connector]
+	if !foundConnector {
+		return StreamableSong{}, errors.Errorf("connector %s not recognized", ref.Connector)
+	}
+
+	song, err := c.GetStreamingUrl(ref.Id)
+	if err != nil {
+		return StreamableSong{}, errors.Wrapf(err, "error getting song id=%s", ref.Id)
+	}
+	return song, nil
+}
+
+// SongDetails fetches the details of a song, unlike Song it does not require the song to be playable
+func (a *app) SongDetails(ref SongRef) (Song, error) {
+	if ref.Connector == DirectConnector {
+		return NewDirectSong(ref.Id).Song, nil
+	}
+
+	c, foundConnector := a.connectors[ref.Connector]
+	if !foundConnector {
+		return Song{}, errors.Errorf("connector %s not recognized", ref.Connector)
+	}
+
+	dp, ok := c.(DetailsProvider)
+	if !ok {
+		song, err := a.Song(ref)
+		return song.Song, err
+	}
+
+	song, err := dp.GetDetails(ref.Id)
+	if err != nil {
+		return Song{}, errors.Wrapf(err, "error getting song id=%s", ref.Id)
+	}
+	return song, nil
+}
+
+func (a *app) Play(ref SongRef) (Player, error) {
+	song, err := a.Song(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error playing song id=%s", ref.Id)
+	}
+
+	player := NewPlayer(song, a.sink)
+	if a.storage.History != nil {
+		player = newRecordingPlayer(player, a.storage.History)
+	}
+	return player, nil
+}
+
+// Alternatives finds song on the other connectors by its name, artists and duration, the most relevant first
+func (a *app) Alternatives(song Song) ([]Song, error) {
+	if song.Name == "" {
+		return nil, errors.Errorf("song %s has no name to search for", song.Ref())
+	}
+	query := strings.TrimSpace(song.Name + " " + song.Artists)
+
+	found, err := a.searchAll(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []Song
+	for _, s := range found {
+		if s.Connector != song.Connector && SameSong(song, s) {
+			candidates = append(candidates, s)
+		}
+	}
+
+	var alternatives []Song
+	for _, g := range RankSongs(query, candidates) {
+		alternatives = append(alternatives, g.Sources()...)
+	}
+	return alternatives, nil
+}
+
+// Recommend finds songs related to song with its connector,
+// or more songs by its main artist when the connector cannot recommend any
+func (a *app) Recommend(song Song) ([]Song, error) {
+	if r, ok := a.connectors[song.Connector].(Recommender); ok {
+		songs, err := r.Recommend(song)
+		if err == nil && len(songs) > 0 {
+			return songs, nil
+		}
+		if err != nil {
+			log.Warn().Msgf("unable to get the songs recommended after %s: %s", song.Ref(), err)
+		}
+	}
+	return a.moreByArtist(song)
+}
+
+// Lyrics fetches the lyrics of a song from its connector, or from the .lrc file next to a local song
+func (a *app) Lyrics(ref SongRef) (Lyrics, error) {
+	if ref.Connector == DirectConnector {
+		return readSidecarLyrics(ref.Id)
+	}
+
+	c, foundConnector := a.connectors[ref.Connector]
+	if !foundConnector {
+		return Lyrics{}, errors.Errorf("connector %s not recognized", ref.Connector)
+	}
+
+	lp, ok := c.(LyricsProvider)
+	if !ok {
+		return Lyrics{}, errors.Errorf("connector %s does not serve lyrics", ref.Connector)
+	}
+	return lp.GetLyrics(ref.Id)
+}
+
+// Playlist fetches a playlist of a streaming service
+func (a *app) Playlist(source PlaylistSource) (Playlist, error) {
+	c, foundConnector := a.connectors[source.Connector]
+	if !foundConnector {
+		return Playlist{}, errors.Errorf("connector %s not recognized", source.Connector)
+	}
+
+	pc, ok := c.(PlaylistConnector)
+	if !ok {
+		return Playlist{}, errors.Errorf("connector %s does not support playlists", source.Connector)
+	}
+
+	playlist, err := pc.GetPlaylist(source.Id)
+	if err != nil {
+		return Playlist{}, errors.Wrapf(err, "error getting playlist id=%s", source.Id)
+	}
+	playlist.Source = &source
+	return playlist, nil
+}
+
+// Chart fetches a chart of a streaming service
+func (a *app) Chart(connectorName string, kind ChartKind, region string) (Chart, error) {
 	c, foundConnector := a.connectors[connectorName]
 	if !foundConnector {
-		return nil, errors.Errorf("connector %s not recognized", connectorName)
+		return Chart{}, errors.Errorf("connector %s not recognized", connectorName)
+	}
+
+	cc, ok := c.(Charts)
+	if !ok {
+		return Chart{}, errors.Errorf("connector %s does not publish charts", connectorName)
 	}
 
-	song, err := c.GetStreamingUrl(id)
+	chart, err := cc.GetChart(kind, region)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error playing song id=%s", id)
+		return Chart{}, errors.Wrapf(err, "error getting %s chart of region %s", kind, region)
 	}
+	return chart, nil
+}
 
-	return NewPlayer(song), nil
+func (a *app) ResolveLink(raw string) (Link, error) {
+	connectors := make([]Connector, 0, len(a.connectors))
+	for _, name := range utils.GetMapKeys(a.connectors) {
+		connectors = append(connectors, a.connectors[name])
+	}
+	return ResolveLink(connectors, raw)
 }
 
 func (a *app) CheckForUpdate() (UpdateStatus, error) {
 	return a.updateNotifier.Check()
 }
+
+func (a *app) Storage() Storage {
+	return a.storage
+}
+
+func (a *app) Preferences() Preferences {
+	return a.prefs
+}
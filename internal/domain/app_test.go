@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockConnector struct {
+	mock.Mock
+	name string
+}
+
+func (m *mockConnector) Name() string {
+	return m.name
+}
+
+func (m *mockConnector) Init() error {
+	return m.Called().Error(0)
+}
+
+func (m *mockConnector) Search(name string) ([]Song, error) {
+	called := m.Called(name)
+	return called.Get(0).([]Song), called.Error(1)
+}
+
+func (m *mockConnector) GetStreamingUrl(id string) (StreamableSong, error) {
+	called := m.Called(id)
+	return called.Get(0).(StreamableSong), called.Error(1)
+}
+
+func Test_app_SearchAll(t *testing.T) {
+	song1 := Song{Id: "1", Connector: "a"}
+	song2 := Song{Id: "2", Connector: "b"}
+	tests := []struct {
+		name    string
+		setup   func(a, b *mockConnector)
+		want    []Song
+		wantErr bool
+	}{
+		{"merges the results in connectors order", func(a, b *mockConnector) {
+			a.On("Search", "term").Return([]Song{song1}, nil)
+			b.On("Search", "term").Return([]Song{song2}, nil)
+		}, []Song{song1, song2}, false},
+		{"ignores the failing connectors", func(a, b *mockConnector) {
+			a.On("Search", "term").Return([]Song(nil), errors.New("unexpected"))
+			b.On("Search", "term").Return([]Song{song2}, nil)
+		}, []Song{song2}, false},
+		{"fails when every connector fails", func(a, b *mockConnector) {
+			a.On("Search", "term").Return([]Song(nil), errors.New("unexpected"))
+			b.On("Search", "term").Return([]Song(nil), errors.New("unexpected"))
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := &mockConnector{name: "a"}, &mockConnector{name: "b"}
+			tt.setup(a, b)
+
+			got, err := NewApp(Preferences{}, nil, nil, Storage{}, b, a).SearchAll("term")
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+			a.AssertExpectations(t)
+			b.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_app_Search_with_alternatives(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Connector: "a"}
+	a := &mockConnector{name: "a"}
+	a.On("Search", "lacj trooi").Return([]Song{}, nil)
+	a.On("Search", "lạc trôi").Return([]Song{song}, nil)
+
+	got, err := NewApp(Preferences{}, nil, nil, Storage{}, a).Search("a", "lacj trooi")
+	require.NoError(t, err)
+	require.Equal(t, []Song{song}, got)
+	a.AssertExpectations(t)
+}
+
+func Test_app_Alternatives(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233 * time.Second, Connector: "a"}
+	same := Song{Id: "x", Name: "Lạc Trôi", Artists: "Sơn Tùng M-TP", Duration: 234 * time.Second, Connector: "b"}
+	remix := Song{Id: "y", Name: "Lạc Trôi (Remix)", Artists: "Sơn Tùng M-TP", Duration: 280 * time.Second, Connector: "b"}
+	a, b := &mockConnector{name: "a"}, &mockConnector{name: "b"}
+	a.On("Search", "Lạc trôi Sơn Tùng M-TP").Return([]Song{song}, nil)
+	b.On("Search", "Lạc trôi Sơn Tùng M-TP").Return([]Song{remix, same}, nil)
+
+	got, err := NewApp(Preferences{}, nil, nil, Storage{}, a, b).Alternatives(song)
+	require.NoError(t, err)
+	require.Equal(t, []Song{same}, got)
+
+	_, err = NewApp(Preferences{}, nil, nil, Storage{}, a, b).Alternatives(Song{Id: "1", Connector: "a"})
+	require.Error(t, err)
+}
+
+func Test_app_Recommend_falls_back_to_the_artist(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP, Triple D", Connector: "a"}
+	other := Song{Id: "2", Name: "Nơi này có anh", Artists: "Sơn Tùng M-TP", Connector: "a"}
+	cover := Song{Id: "3", Name: "Lạc trôi (Cover)", Artists: "Someone", Connector: "a"}
+	a := &mockConnector{name: "a"}
+	a.On("Search", "Sơn Tùng M-TP").Return([]Song{song, other, cover}, nil)
+
+	got, err := NewApp(Preferences{}, nil, nil, Storage{}, a).Recommend(song)
+	require.NoError(t, err)
+	require.Equal(t, []Song{other}, got)
+
+	_, err = NewApp(Preferences{}, nil, nil, Storage{}, a).Recommend(Song{Id: "1", Connector: "a"})
+	require.Error(t, err)
+	a.AssertExpectations(t)
+}
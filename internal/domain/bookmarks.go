@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/utils"
+)
+
+type Bookmark struct {
+	Name string        `json:"name"`
+	Pos  time.Duration `json:"pos"`
+}
+
+type BookmarkStore interface {
+	List(song Song) ([]Bookmark, error)
+	Get(song Song, name string) (Bookmark, error)
+	Save(song Song, bookmark Bookmark) error
+	Delete(song Song, name string) error
+}
+
+type bookmarkStore struct {
+	sync.Mutex
+	path string
+}
+
+func NewBookmarkStore(path string) BookmarkStore {
+	return &bookmarkStore{path: path}
+}
+
+func (s *bookmarkStore) load() (map[SongRef][]Bookmark, error) {
+	bookmarks := make(map[SongRef][]Bookmark)
+	if err := utils.ReadJSON(s.path, &bookmarks); err != nil {
+		return nil, errors.Wrap(err, "error loading bookmarks")
+	}
+	return bookmarks, nil
+}
+
+// List returns the bookmarks of song ordered by position
+func (s *bookmarkStore) List(song Song) ([]Bookmark, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	bookmarks, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks[song.Ref()], nil
+}
+
+func (s *bookmarkStore) Get(song Song, name string) (Bookmark, error) {
+	bookmarks, err := s.List(song)
+	if err != nil {
+		return Bookmark{}, err
+	}
+
+	for _, b := range bookmarks {
+		if b.Name == name {
+			return b, nil
+		}
+	}
+	return Bookmark{}, errors.Errorf("bookmark %s not found", name)
+}
+
+// Save adds bookmark to song, replacing the one with the same name if any
+func (s *bookmarkStore) Save(song Song, bookmark Bookmark) error {
+	if bookmark.Name == "" {
+		return errors.New("bookmark name must not be empty")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	bookmarks, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	key := song.Ref()
+	list := []Bookmark{bookmark}
+	for _, b := range bookmarks[key] {
+		if b.Name != bookmark.Name {
+			list = append(list, b)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Pos < list[j].Pos })
+	bookmarks[key] = list
+
+	return errors.Wrap(utils.WriteJSON(s.path, bookmarks), "error saving bookmarks")
+}
+
+func (s *bookmarkStore) Delete(song Song, name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	bookmarks, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	key := song.Ref()
+	var list []Bookmark
+	for _, b := range bookmarks[key] {
+		if b.Name != name {
+			list = append(list, b)
+		}
+	}
+	if len(list) == len(bookmarks[key]) {
+		return errors.Errorf("bookmark %s not found", name)
+	}
+
+	if len(list) == 0 {
+		delete(bookmarks, key)
+	} else {
+		bookmarks[key] = list
+	}
+	return errors.Wrap(utils.WriteJSON(s.path, bookmarks), "error saving bookmarks")
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bookmarkStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	song := Song{Id: "id1", Connector: "c"}
+	other := Song{Id: "id2", Connector: "c"}
+
+	s := NewBookmarkStore(path)
+	bookmarks, err := s.List(song)
+	require.NoError(t, err)
+	require.Empty(t, bookmarks)
+
+	require.NoError(t, s.Save(song, Bookmark{Name: "chorus", Pos: time.Minute}))
+	require.NoError(t, s.Save(song, Bookmark{Name: "intro", Pos: 10 * time.Second}))
+	require.NoError(t, s.Save(other, Bookmark{Name: "bridge", Pos: 2 * time.Minute}))
+	require.NoError(t, s.Save(song, Bookmark{Name: "chorus", Pos: 70 * time.Second}))
+	require.Error(t, s.Save(song, Bookmark{Pos: time.Second}))
+
+	// reopen to make sure bookmarks are persisted
+	s = NewBookmarkStore(path)
+	bookmarks, err = s.List(song)
+	require.NoError(t, err)
+	require.Equal(t, []Bookmark{{"intro", 10 * time.Second}, {"chorus", 70 * time.Second}}, bookmarks)
+
+	got, err := s.Get(song, "chorus")
+	require.NoError(t, err)
+	require.Equal(t, 70*time.Second, got.Pos)
+	_, err = s.Get(song, "bridge")
+	require.Error(t, err)
+
+	require.NoError(t, s.Delete(song, "intro"))
+	require.Error(t, s.Delete(song, "intro"))
+	bookmarks, err = s.List(song)
+	require.NoError(t, err)
+	require.Equal(t, []Bookmark{{"chorus", 70 * time.Second}}, bookmarks)
+}
+
+func Test_bookmarkStore_reads_legacy_keys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"zmp3.id1":[{"name":"chorus","pos":60000000000}]}`), 0644))
+
+	s := NewBookmarkStore(path)
+	bookmark, err := s.Get(Song{Id: "id1", Connector: "zmp3"}, "chorus")
+	require.NoError(t, err)
+	require.Equal(t, Bookmark{Name: "chorus", Pos: time.Minute}, bookmark)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChartKind tells which chart of a service to browse
+type ChartKind string
+
+const (
+	// ChartTop is the most played songs of the moment, e.g. #zingchart or the nct top 20
+	ChartTop         ChartKind = "top"
+	ChartNewReleases ChartKind = "new"
+)
+
+var ChartKinds = []ChartKind{ChartTop, ChartNewReleases}
+
+// ChartRegions lists the regions the charts are published for: Vietnam, US-UK and Korea
+var ChartRegions = []string{"vn", "us", "kr"}
+
+const DefaultChartRegion = "vn"
+
+// Chart is a ranking of songs, the first one is the top of the chart
+type Chart struct {
+	Name   string
+	Kind   ChartKind
+	Region string
+	Songs  []Song
+}
+
+// Charts is implemented by the connectors publishing the charts of their service
+type Charts interface {
+	GetChart(kind ChartKind, region string) (Chart, error)
+}
+
+func ParseChartKind(s string) (ChartKind, error) {
+	for _, kind := range ChartKinds {
+		if strings.EqualFold(s, string(kind)) {
+			return kind, nil
+		}
+	}
+	return "", errors.Errorf("chart %s not recognized, expected one of %v", s, ChartKinds)
+}
+
+func ParseChartRegion(s string) (string, error) {
+	for _, region := range ChartRegions {
+		if strings.EqualFold(s, region) {
+			return region, nil
+		}
+	}
+	return "", errors.Errorf("region %s not recognized, expected one of %v", s, ChartRegions)
+}
@@ -2,9 +2,15 @@ package domain
 
 import (
 	"net/http"
+	"path"
+	"strings"
 	"time"
 )
 
+// DirectConnector plays the songs whose id is their streaming url or a local file path,
+// such as the ones imported from playlist files
+const DirectConnector = "url"
+
 type Connector interface {
 	Name() string
 	Init() error
@@ -12,12 +18,53 @@ type Connector interface {
 	GetStreamingUrl(id string) (StreamableSong, error)
 }
 
+// DetailsProvider is implemented by the connectors able to fetch the details of a song without a playable stream
+type DetailsProvider interface {
+	GetDetails(id string) (Song, error)
+}
+
+// PlaylistConnector is implemented by the connectors able to fetch the playlists of their service
+type PlaylistConnector interface {
+	GetPlaylist(id string) (Playlist, error)
+}
+
 type Song struct {
-	Id        string
-	Name      string
-	Artists   string
-	Duration  time.Duration
-	Connector string
+	Id        string        `json:"id"`
+	Name      string        `json:"name"`
+	Artists   string        `json:"artists"` // the names of ArtistList, comma separated
+	Duration  time.Duration `json:"duration"`
+	Connector string        `json:"connector"`
+
+	// details left empty when the connector does not provide them
+	ArtistList []Artist `json:"artistList,omitempty"`
+	Album      *Album   `json:"album,omitempty"`
+	Year       int      `json:"year,omitempty"`
+	Genres     []string `json:"genres,omitempty"`
+	Thumbnail  string   `json:"thumbnail,omitempty"` // url of the cover art
+	Explicit   bool     `json:"explicit,omitempty"`
+}
+
+type Artist struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Album struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// JoinArtists flattens artists into the Artists field of a song
+func JoinArtists(artists []Artist) string {
+	names := make([]string, len(artists))
+	for idx, artist := range artists {
+		names[idx] = artist.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (s Song) Ref() SongRef {
+	return SongRef{Connector: s.Connector, Id: s.Id}
 }
 
 type StreamableSong struct {
@@ -25,6 +72,13 @@ type StreamableSong struct {
 	StreamingUrl string
 }
 
+func NewDirectSong(location string) StreamableSong {
+	return StreamableSong{
+		Song:         Song{Id: location, Name: path.Base(location), Connector: DirectConnector},
+		StreamingUrl: location,
+	}
+}
+
 type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HistoryEntry records one playback of a song
+type HistoryEntry struct {
+	Song      Song
+	StartedAt time.Time
+	Listened  time.Duration
+	Skipped   bool
+}
+
+// historyLine is an entry as saved in the history file, its song is keyed by its ref
+type historyLine struct {
+	Song      storedSong    `json:"song"`
+	StartedAt time.Time     `json:"startedAt"`
+	Listened  time.Duration `json:"listened"`
+	Skipped   bool          `json:"skipped"`
+}
+
+// HistoryFilter selects history entries, zero fields match everything
+type HistoryFilter struct {
+	Since       time.Time
+	Until       time.Time
+	Connector   string
+	Term        string // matches the song or the artists names, see MatchSong
+	SkippedOnly bool
+	Limit       int // keeps the most recent entries only
+}
+
+func (f HistoryFilter) match(e HistoryEntry) bool {
+	if !f.Since.IsZero() && e.StartedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.StartedAt.Before(f.Until) {
+		return false
+	}
+	if f.Connector != "" && e.Song.Connector != f.Connector {
+		return false
+	}
+	if f.SkippedOnly && !e.Skipped {
+		return false
+	}
+	if f.Term != "" && !MatchSong(e.Song, f.Term) {
+		return false
+	}
+	return true
+}
+
+type History interface {
+	Record(entry HistoryEntry) error
+	// List returns the entries matching filter, oldest first
+	List(filter HistoryFilter) ([]HistoryEntry, error)
+}
+
+// history appends entries to a JSON lines file so recording never rewrites the past ones
+type history struct {
+	sync.Mutex
+	path string
+}
+
+func NewHistory(path string) History {
+	return &history{path: path}
+}
+
+func (h *history) Record(entry HistoryEntry) error {
+	h.Lock()
+	defer h.Unlock()
+
+	data, err := json.Marshal(historyLine{
+		Song:      newStoredSong(entry.Song),
+		StartedAt: entry.StartedAt,
+		Listened:  entry.Listened,
+		Skipped:   entry.Skipped,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error encoding history entry")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory of %s", h.path)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error opening history")
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return errors.Wrap(err, "error recording history")
+}
+
+func (h *history) List(filter HistoryFilter) ([]HistoryEntry, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening history")
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line historyLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// a line may have been truncated by a crash, it should not hide the others
+			continue
+		}
+		entry := HistoryEntry{Song: line.Song.song(), StartedAt: line.StartedAt, Listened: line.Listened, Skipped: line.Skipped}
+		if filter.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading history")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// recordingPlayer records its playback into the history once it is done
+type recordingPlayer struct {
+	Player
+	history History
+
+	sync.Mutex
+	skipped bool
+}
+
+func newRecordingPlayer(player Player, history History) Player {
+	return &recordingPlayer{Player: player, history: history}
+}
+
+func (p *recordingPlayer) Start() error {
+	startedAt := time.Now()
+	err := p.Player.Start()
+
+	status := p.Player.Report()
+	if err != nil && status.Listened == 0 {
+		// never played
+		return err
+	}
+
+	p.Lock()
+	skipped := p.skipped
+	p.Unlock()
+
+	// the history is a nice to have, it must not break the playback
+	p.history.Record(HistoryEntry{
+		Song:      status.Song.Song,
+		StartedAt: startedAt,
+		Listened:  status.Listened.Round(time.Second),
+		Skipped:   skipped,
+	})
+	return err
+}
+
+// Skip stops the song because the user moved to another one, unlike Stop it is recorded as skipped
+func (p *recordingPlayer) Skip() {
+	p.Lock()
+	p.skipped = true
+	p.Unlock()
+
+	p.Player.Stop()
+}
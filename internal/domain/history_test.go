@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_history_List(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h := NewHistory(path)
+	start := time.Date(2021, 6, 1, 20, 0, 0, 0, time.UTC)
+	entries := []HistoryEntry{
+		{Song: Song{Id: "1", Name: "Hello", Artists: "Adele", Connector: "zing"}, StartedAt: start, Listened: time.Minute},
+		{Song: Song{Id: "2", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "nct"}, StartedAt: start.Add(time.Hour), Listened: 5 * time.Second, Skipped: true},
+		{Song: Song{Id: "1", Name: "Hello", Artists: "Adele", Connector: "zing"}, StartedAt: start.Add(2 * time.Hour), Listened: 3 * time.Minute},
+	}
+	for _, e := range entries {
+		require.NoError(t, h.Record(e))
+	}
+	saved, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(saved), `"song":{"ref":"zing:1"`, "the songs must be keyed by their refs")
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []HistoryEntry
+	}{
+		{"all", HistoryFilter{}, entries},
+		{"since", HistoryFilter{Since: start.Add(time.Hour)}, entries[1:]},
+		{"until", HistoryFilter{Until: start.Add(time.Hour)}, entries[:1]},
+		{"connector", HistoryFilter{Connector: "nct"}, entries[1:2]},
+		{"term", HistoryFilter{Term: "adele"}, []HistoryEntry{entries[0], entries[2]}},
+		{"term without tone marks", HistoryFilter{Term: "lac troi"}, entries[1:2]},
+		{"term typed with telex", HistoryFilter{Term: "sown tungf"}, entries[1:2]},
+		{"skipped", HistoryFilter{SkippedOnly: true}, entries[1:2]},
+		{"limit", HistoryFilter{Limit: 2}, entries[1:]},
+		{"none", HistoryFilter{Connector: "toto"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.List(tt.filter)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.want), len(got))
+			for i := range tt.want {
+				require.True(t, tt.want[i].StartedAt.Equal(got[i].StartedAt))
+				require.Equal(t, tt.want[i].Song, got[i].Song)
+				require.Equal(t, tt.want[i].Listened, got[i].Listened)
+				require.Equal(t, tt.want[i].Skipped, got[i].Skipped)
+			}
+		})
+	}
+}
+
+func Test_history_List_without_file(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	got, err := h.List(HistoryFilter{})
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func Test_recordingPlayer(t *testing.T) {
+	tests := []struct {
+		name        string
+		player      *fakePlayer
+		stop        func(p Player)
+		wantSkipped bool
+	}{
+		{"played until the end", newFakePlayer(10*time.Millisecond, nil), nil, false},
+		{"stopped", newFakePlayer(0, nil), Player.Stop, false},
+		{"skipped", newFakePlayer(0, nil), skip, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+			p := newRecordingPlayer(tt.player, h)
+			if tt.stop != nil {
+				tt.stop(p)
+			}
+			require.NoError(t, p.Start())
+
+			entries, err := h.List(HistoryFilter{})
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.Equal(t, tt.wantSkipped, entries[0].Skipped)
+		})
+	}
+}
+
+func Test_stopwatch(t *testing.T) {
+	var sw stopwatch
+	require.Zero(t, sw.Elapsed())
+
+	sw.Start()
+	time.Sleep(20 * time.Millisecond)
+	sw.Stop()
+	elapsed := sw.Elapsed()
+	require.GreaterOrEqual(t, int64(elapsed), int64(20*time.Millisecond))
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, elapsed, sw.Elapsed(), "a stopped stopwatch must not count")
+}
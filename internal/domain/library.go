@@ -0,0 +1,306 @@
+package domain
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/utils"
+	"io.github.binatory/budich-cli/internal/vietnamese"
+)
+
+type Playlist struct {
+	Name  string `json:"name"`
+	Songs []Song `json:"songs"`
+
+	// the playlist of a streaming service this one has been copied from, nil if none
+	Source *PlaylistSource `json:"source,omitempty"`
+}
+
+type PlaylistSource struct {
+	Connector string `json:"connector"`
+	Id        string `json:"id"`
+}
+
+// ParsePlaylistSource parses <connector>:<id>, like the songs refs
+func ParsePlaylistSource(s string) (PlaylistSource, error) {
+	connector, id, err := parseRef(s)
+	if err != nil {
+		return PlaylistSource{}, errors.Errorf("invalid playlist %s, expected <connector>:<id>", s)
+	}
+	return PlaylistSource{Connector: connector, Id: id}, nil
+}
+
+type Library interface {
+	Favorites() ([]Song, error)
+	IsFavorite(song Song) (bool, error)
+	AddFavorite(song Song) error
+	RemoveFavorite(song Song) error
+
+	Playlists() ([]Playlist, error)
+	Playlist(name string) (Playlist, error)
+	CreatePlaylist(name string) error
+	ImportPlaylist(playlist Playlist) error
+	DeletePlaylist(name string) error
+	AddToPlaylist(name string, songs ...Song) error
+	RemoveFromPlaylist(name string, song Song) error
+
+	// Search returns the favorites and the songs of the playlists matching query, each song once
+	Search(query string) ([]Song, error)
+}
+
+type libraryData struct {
+	Favorites []Song
+	Playlists map[string]Playlist
+}
+
+// libraryFile is the library as saved, its songs are keyed by their refs
+type libraryFile struct {
+	Favorites []storedSong              `json:"favorites"`
+	Playlists map[string]storedPlaylist `json:"playlists"`
+}
+
+type storedPlaylist struct {
+	Name   string          `json:"name"`
+	Songs  []storedSong    `json:"songs"`
+	Source *PlaylistSource `json:"source,omitempty"`
+}
+
+type library struct {
+	sync.Mutex
+	path string
+}
+
+func NewLibrary(path string) Library {
+	return &library{path: path}
+}
+
+func (l *library) load() (libraryData, error) {
+	var file libraryFile
+	if err := utils.ReadJSON(l.path, &file); err != nil {
+		return libraryData{}, errors.Wrap(err, "error loading library")
+	}
+
+	data := libraryData{Favorites: songsOf(file.Favorites), Playlists: make(map[string]Playlist, len(file.Playlists))}
+	for name, p := range file.Playlists {
+		data.Playlists[name] = Playlist{Name: p.Name, Songs: songsOf(p.Songs), Source: p.Source}
+	}
+	return data, nil
+}
+
+func (l *library) save(data libraryData) error {
+	file := libraryFile{Favorites: newStoredSongs(data.Favorites), Playlists: make(map[string]storedPlaylist, len(data.Playlists))}
+	for name, p := range data.Playlists {
+		file.Playlists[name] = storedPlaylist{Name: p.Name, Songs: newStoredSongs(p.Songs), Source: p.Source}
+	}
+	return errors.Wrap(utils.WriteJSON(l.path, file), "error saving library")
+}
+
+// update loads the library, applies f then saves the library unless f fails
+func (l *library) update(f func(data *libraryData) error) error {
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := l.load()
+	if err != nil {
+		return err
+	}
+	if err := f(&data); err != nil {
+		return err
+	}
+	return l.save(data)
+}
+
+func (l *library) Favorites() ([]Song, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := l.load()
+	return data.Favorites, err
+}
+
+func (l *library) IsFavorite(song Song) (bool, error) {
+	favorites, err := l.Favorites()
+	if err != nil {
+		return false, err
+	}
+	return indexOfSong(favorites, song) >= 0, nil
+}
+
+func (l *library) AddFavorite(song Song) error {
+	return l.update(func(data *libraryData) error {
+		if indexOfSong(data.Favorites, song) < 0 {
+			data.Favorites = append(data.Favorites, song)
+		}
+		return nil
+	})
+}
+
+func (l *library) RemoveFavorite(song Song) error {
+	return l.update(func(data *libraryData) error {
+		idx := indexOfSong(data.Favorites, song)
+		if idx < 0 {
+			return errors.Errorf("song %s is not a favorite", song.Ref())
+		}
+		data.Favorites = append(data.Favorites[:idx], data.Favorites[idx+1:]...)
+		return nil
+	})
+}
+
+// Playlists returns every playlist ordered by name
+func (l *library) Playlists() ([]Playlist, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, len(data.Playlists))
+	for _, name := range utils.GetMapKeys(data.Playlists) {
+		playlists = append(playlists, data.Playlists[name])
+	}
+	return playlists, nil
+}
+
+func (l *library) Playlist(name string) (Playlist, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := l.load()
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	playlist, found := data.Playlists[name]
+	if !found {
+		return Playlist{}, errors.Errorf("playlist %s not found", name)
+	}
+	return playlist, nil
+}
+
+func (l *library) CreatePlaylist(name string) error {
+	if name == "" {
+		return errors.New("playlist name must not be empty")
+	}
+
+	return l.update(func(data *libraryData) error {
+		if _, found := data.Playlists[name]; found {
+			return errors.Errorf("playlist %s already exists", name)
+		}
+		data.Playlists[name] = Playlist{Name: name, Songs: []Song{}}
+		return nil
+	})
+}
+
+// ImportPlaylist saves a new playlist along with its songs and its source
+func (l *library) ImportPlaylist(playlist Playlist) error {
+	if playlist.Name == "" {
+		return errors.New("playlist name must not be empty")
+	}
+	if playlist.Songs == nil {
+		playlist.Songs = []Song{}
+	}
+
+	return l.update(func(data *libraryData) error {
+		if _, found := data.Playlists[playlist.Name]; found {
+			return errors.Errorf("playlist %s already exists", playlist.Name)
+		}
+		data.Playlists[playlist.Name] = playlist
+		return nil
+	})
+}
+
+func (l *library) DeletePlaylist(name string) error {
+	return l.update(func(data *libraryData) error {
+		if _, found := data.Playlists[name]; !found {
+			return errors.Errorf("playlist %s not found", name)
+		}
+		delete(data.Playlists, name)
+		return nil
+	})
+}
+
+// AddToPlaylist appends songs to the playlist, skipping the ones already in it
+func (l *library) AddToPlaylist(name string, songs ...Song) error {
+	return l.update(func(data *libraryData) error {
+		playlist, found := data.Playlists[name]
+		if !found {
+			return errors.Errorf("playlist %s not found", name)
+		}
+
+		for _, song := range songs {
+			if indexOfSong(playlist.Songs, song) < 0 {
+				playlist.Songs = append(playlist.Songs, song)
+			}
+		}
+		data.Playlists[name] = playlist
+		return nil
+	})
+}
+
+func (l *library) RemoveFromPlaylist(name string, song Song) error {
+	return l.update(func(data *libraryData) error {
+		playlist, found := data.Playlists[name]
+		if !found {
+			return errors.Errorf("playlist %s not found", name)
+		}
+
+		idx := indexOfSong(playlist.Songs, song)
+		if idx < 0 {
+			return errors.Errorf("song %s is not in playlist %s", song.Ref(), name)
+		}
+		playlist.Songs = append(playlist.Songs[:idx], playlist.Songs[idx+1:]...)
+		data.Playlists[name] = playlist
+		return nil
+	})
+}
+
+func (l *library) Search(query string) ([]Song, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	data, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var songs []Song
+	add := func(candidates []Song) {
+		for _, song := range FilterSongs(candidates, query) {
+			if indexOfSong(songs, song) < 0 {
+				songs = append(songs, song)
+			}
+		}
+	}
+	add(data.Favorites)
+	for _, name := range utils.GetMapKeys(data.Playlists) {
+		add(data.Playlists[name].Songs)
+	}
+	return songs, nil
+}
+
+// FilterSongs keeps the songs whose name or artists match query, whatever the diacritics and the input method
+func FilterSongs(songs []Song, query string) []Song {
+	var filtered []Song
+	for _, song := range songs {
+		if MatchSong(song, query) {
+			filtered = append(filtered, song)
+		}
+	}
+	return filtered
+}
+
+func MatchSong(song Song, query string) bool {
+	return vietnamese.Match(song.Name+" "+song.Artists, query)
+}
+
+func indexOfSong(songs []Song, song Song) int {
+	ref := song.Ref()
+	for idx, s := range songs {
+		if s.Ref() == ref {
+			return idx
+		}
+	}
+	return -1
+}
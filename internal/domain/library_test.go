@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_library_Favorites(t *testing.T) {
+	l := NewLibrary(filepath.Join(t.TempDir(), "library.json"))
+	song1 := Song{Id: "1", Name: "Song 1", Connector: "c"}
+	song2 := Song{Id: "2", Name: "Song 2", Connector: "c"}
+
+	favorites, err := l.Favorites()
+	require.NoError(t, err)
+	require.Empty(t, favorites)
+
+	require.NoError(t, l.AddFavorite(song1))
+	require.NoError(t, l.AddFavorite(song2))
+	require.NoError(t, l.AddFavorite(song1))
+	favorites, err = l.Favorites()
+	require.NoError(t, err)
+	require.Equal(t, []Song{song1, song2}, favorites)
+
+	isFavorite, err := l.IsFavorite(Song{Id: "2", Connector: "c"})
+	require.NoError(t, err)
+	require.True(t, isFavorite)
+
+	require.NoError(t, l.RemoveFavorite(song2))
+	require.Error(t, l.RemoveFavorite(song2))
+	isFavorite, err = l.IsFavorite(song2)
+	require.NoError(t, err)
+	require.False(t, isFavorite)
+}
+
+func Test_library_Playlists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.json")
+	l := NewLibrary(path)
+	song1 := Song{Id: "1", Name: "Song 1", Connector: "c"}
+	song2 := Song{Id: "2", Name: "Song 2", Connector: "c"}
+
+	require.NoError(t, l.CreatePlaylist("morning"))
+	require.NoError(t, l.CreatePlaylist("evening"))
+	require.Error(t, l.CreatePlaylist("morning"))
+	require.Error(t, l.CreatePlaylist(""))
+
+	require.NoError(t, l.AddToPlaylist("morning", song1, song2, song1))
+	require.Error(t, l.AddToPlaylist("night", song1))
+
+	// the songs are keyed by their refs
+	saved, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(saved), `"ref": "c:1"`)
+	require.NotContains(t, string(saved), `"connector"`)
+
+	// reopen to make sure playlists are persisted
+	l = NewLibrary(path)
+	playlists, err := l.Playlists()
+	require.NoError(t, err)
+	require.Equal(t, []Playlist{
+		{Name: "evening", Songs: []Song{}},
+		{Name: "morning", Songs: []Song{song1, song2}},
+	}, playlists)
+
+	require.NoError(t, l.RemoveFromPlaylist("morning", song1))
+	require.Error(t, l.RemoveFromPlaylist("morning", song1))
+	playlist, err := l.Playlist("morning")
+	require.NoError(t, err)
+	require.Equal(t, []Song{song2}, playlist.Songs)
+
+	require.NoError(t, l.DeletePlaylist("evening"))
+	require.Error(t, l.DeletePlaylist("evening"))
+	_, err = l.Playlist("evening")
+	require.Error(t, err)
+}
+
+func Test_library_Search(t *testing.T) {
+	l := NewLibrary(filepath.Join(t.TempDir(), "library.json"))
+	song1 := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	song2 := Song{Id: "2", Name: "Nơi này có anh", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	song3 := Song{Id: "3", Name: "Hello", Artists: "Adele", Connector: "c"}
+
+	require.NoError(t, l.AddFavorite(song1))
+	require.NoError(t, l.CreatePlaylist("morning"))
+	require.NoError(t, l.AddToPlaylist("morning", song1, song2, song3))
+
+	tests := []struct {
+		query string
+		want  []Song
+	}{
+		{"Lạc trôi", []Song{song1}},
+		{"lac troi", []Song{song1}},
+		{"lacj trooi", []Song{song1}},
+		{"la5c tro6i", []Song{song1}},
+		{"son tung", []Song{song1, song2}},
+		{"noi nay", []Song{song2}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := l.Search(tt.query)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type LinkKind string
+
+const (
+	LinkSong     LinkKind = "song"
+	LinkPlaylist LinkKind = "playlist"
+)
+
+// Link is what a share url of a streaming service points to
+type Link struct {
+	Kind      LinkKind
+	Connector string
+	Id        string
+}
+
+// LinkResolver is implemented by the connectors recognizing the share urls of their service
+type LinkResolver interface {
+	ResolveLink(u *url.URL) (Link, bool)
+}
+
+// ResolveLink finds the connector handling the share url raw
+func ResolveLink(connectors []Connector, raw string) (Link, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return Link{}, errors.Errorf("invalid url %s", raw)
+	}
+
+	for _, c := range connectors {
+		if resolver, ok := c.(LinkResolver); ok {
+			if link, ok := resolver.ResolveLink(u); ok {
+				return link, nil
+			}
+		}
+	}
+	return Link{}, errors.Errorf("url %s is not supported by any connector", raw)
+}
+
+// matchHost tells whether host is domain or one of its subdomains
+func matchHost(host, domain string) bool {
+	host = strings.ToLower(host)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// linkPath splits the path of a share url such as /bai-hat/Lac-Troi/ZWAFE8BC.html
+// into its first segment and the last one without its extension
+func linkPath(u *url.URL) (string, string) {
+	p := strings.Trim(u.Path, "/")
+	first := strings.SplitN(p, "/", 2)[0]
+	last := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	return first, last
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLink(t *testing.T) {
+	connectors := []Connector{NewConnectorZingMp3(nil), NewConnectorNhacCuaTui(nil)}
+	tests := []struct {
+		name    string
+		raw     string
+		want    Link
+		wantErr bool
+	}{
+		{"zmp3 song", "https://zingmp3.vn/bai-hat/Lac-Troi-Son-Tung-M-TP/ZW6BFBB7.html", Link{Kind: LinkSong, Connector: "zmp3", Id: "ZW6BFBB7"}, false},
+		{"nct song", "https://www.nhaccuatui.com/bai-hat/lac-troi-son-tung-m-tp.FhUQpMPTyXmI.html", Link{Kind: LinkSong, Connector: "nct", Id: "FhUQpMPTyXmI"}, false},
+		{"zmp3 album", "https://zingmp3.vn/album/Sky-Tour-Son-Tung-M-TP/ZWZB969E.html", Link{Kind: LinkPlaylist, Connector: "zmp3", Id: "ZWZB969E"}, false},
+		{"zmp3 playlist", "https://m.zingmp3.vn/playlist/Top-100/ZWZB96AB.html?utm=share", Link{Kind: LinkPlaylist, Connector: "zmp3", Id: "ZWZB96AB"}, false},
+		{"nct playlist", "https://www.nhaccuatui.com/playlist/nhac-tre-hay.Gx3ZK0lCmHqK.html", Link{Kind: LinkPlaylist, Connector: "nct", Id: "Gx3ZK0lCmHqK"}, false},
+		{"unknown page", "https://zingmp3.vn/zing-chart", Link{}, true},
+		{"unknown host", "https://example.com/playlist/a.b.html", Link{}, true},
+		{"not an url", "ZWZB969E", Link{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveLink(connectors, tt.raw)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
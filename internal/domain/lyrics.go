@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LyricsProvider is implemented by the connectors serving the lyrics of their songs
+type LyricsProvider interface {
+	GetLyrics(id string) (Lyrics, error)
+}
+
+// LyricsLine is a line of lyrics, Time is when it starts being sung and is zero when the lyrics are not synced
+type LyricsLine struct {
+	Time time.Duration
+	Text string
+}
+
+type Lyrics struct {
+	Lines  []LyricsLine
+	Synced bool
+}
+
+// LineAt returns the index of the line sung at pos, -1 before the first one or when the lyrics are not synced
+func (l Lyrics) LineAt(pos time.Duration) int {
+	if !l.Synced {
+		return -1
+	}
+	return sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > pos
+	}) - 1
+}
+
+var (
+	lrcTimeTagRegex = regexp.MustCompile(`^\[(\d+):(\d{1,2}(?:[.:]\d{1,3})?)\]`)
+	lrcInfoTagRegex = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseLRC reads lyrics in the LRC format, a line may have several time tags and the info tags
+// are ignored except the offset. Lyrics without any time tag are read as plain text
+func ParseLRC(r io.Reader) (Lyrics, error) {
+	var lines, plain []LyricsLine
+	var offset time.Duration
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := lrcInfoTagRegex.FindStringSubmatch(line); m != nil {
+			if strings.EqualFold(m[1], "offset") {
+				// a positive offset shifts the lyrics up, i.e. sooner
+				if ms, err := strconv.Atoi(strings.TrimSpace(m[2])); err == nil {
+					offset = time.Duration(ms) * time.Millisecond
+				}
+			}
+			continue
+		}
+
+		var times []time.Duration
+		for {
+			m := lrcTimeTagRegex.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			minutes, _ := strconv.Atoi(m[1])
+			seconds, _ := strconv.ParseFloat(strings.Replace(m[2], ":", ".", 1), 64)
+			times = append(times, time.Duration(minutes)*time.Minute+time.Duration(seconds*float64(time.Second)))
+			line = line[len(m[0]):]
+		}
+
+		text := strings.TrimSpace(line)
+		if len(times) == 0 {
+			plain = append(plain, LyricsLine{Text: text})
+		}
+		for _, t := range times {
+			lines = append(lines, LyricsLine{Time: t, Text: text})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Lyrics{}, errors.Wrap(err, "error reading lyrics")
+	}
+
+	if len(lines) == 0 {
+		return Lyrics{Lines: trimBlankLines(plain)}, nil
+	}
+
+	for i := range lines {
+		if lines[i].Time -= offset; lines[i].Time < 0 {
+			lines[i].Time = 0
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time < lines[j].Time
+	})
+	return Lyrics{Lines: lines, Synced: true}, nil
+}
+
+var htmlBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// ParsePlainLyrics reads unsynced lyrics, the line breaks may be html ones
+func ParsePlainLyrics(text string) Lyrics {
+	text = htmlBreakRegex.ReplaceAllString(text, "\n")
+	var lines []LyricsLine
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, LyricsLine{Text: strings.TrimSpace(line)})
+	}
+	return Lyrics{Lines: trimBlankLines(lines)}
+}
+
+func trimBlankLines(lines []LyricsLine) []LyricsLine {
+	for len(lines) > 0 && lines[0].Text == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1].Text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// fetchLRC downloads then parses a LRC file, the connectors serve some lyrics this way
+func fetchLRC(httpClient HttpClient, lrcUrl string) (Lyrics, error) {
+	req, err := http.NewRequest(http.MethodGet, lrcUrl, nil)
+	if err != nil {
+		return Lyrics{}, errors.Wrapf(err, "error creating request %s", lrcUrl)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Lyrics{}, errors.Wrapf(err, "error fetching lyrics %s", lrcUrl)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Lyrics{}, errors.Errorf("error fetching lyrics %s, got status code %d", lrcUrl, resp.StatusCode)
+	}
+	return ParseLRC(resp.Body)
+}
+
+// readSidecarLyrics reads the .lrc file next to a local song, e.g. song.lrc for song.mp3
+func readSidecarLyrics(location string) (Lyrics, error) {
+	path := location
+	if u, err := url.Parse(location); err == nil {
+		switch u.Scheme {
+		case "http", "https":
+			return Lyrics{}, errors.Errorf("no lyrics for remote song %s", location)
+		case "file":
+			path = u.Path
+		}
+	}
+
+	lrcPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".lrc"
+	f, err := os.Open(lrcPath)
+	if os.IsNotExist(err) {
+		return Lyrics{}, errors.Errorf("no lyrics found for %s, expected them in %s", location, lrcPath)
+	}
+	if err != nil {
+		return Lyrics{}, errors.Wrapf(err, "error opening %s", lrcPath)
+	}
+	defer f.Close()
+
+	return ParseLRC(f)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const lrc = `[ar:Sơn Tùng M-TP]
+[ti:Lạc trôi]
+[offset:500]
+
+[00:12.50]Người theo hương hoa mây mù giăng lối
+[00:18.00][01:30.00]Làn sương khói phôi phai đưa bước ai xa rồi
+`
+
+func TestParseLRC(t *testing.T) {
+	got, err := ParseLRC(strings.NewReader(lrc))
+	require.NoError(t, err)
+	require.Equal(t, Lyrics{Synced: true, Lines: []LyricsLine{
+		{Time: 12 * time.Second, Text: "Người theo hương hoa mây mù giăng lối"},
+		{Time: 17500 * time.Millisecond, Text: "Làn sương khói phôi phai đưa bước ai xa rồi"},
+		{Time: 89500 * time.Millisecond, Text: "Làn sương khói phôi phai đưa bước ai xa rồi"},
+	}}, got)
+}
+
+func TestParseLRC_without_time_tags(t *testing.T) {
+	got, err := ParseLRC(strings.NewReader("\nline 1\n\nline 2\n"))
+	require.NoError(t, err)
+	require.Equal(t, Lyrics{Lines: []LyricsLine{{Text: "line 1"}, {Text: ""}, {Text: "line 2"}}}, got)
+}
+
+func TestParsePlainLyrics(t *testing.T) {
+	require.Equal(t, Lyrics{Lines: []LyricsLine{{Text: "line 1"}, {Text: "line 2"}}}, ParsePlainLyrics("line 1<br/>line 2<BR>"))
+}
+
+func TestLyrics_LineAt(t *testing.T) {
+	lyrics := Lyrics{Synced: true, Lines: []LyricsLine{{Time: 10 * time.Second}, {Time: 20 * time.Second}}}
+	require.Equal(t, -1, lyrics.LineAt(5*time.Second))
+	require.Equal(t, 0, lyrics.LineAt(10*time.Second))
+	require.Equal(t, 0, lyrics.LineAt(15*time.Second))
+	require.Equal(t, 1, lyrics.LineAt(time.Minute))
+	require.Equal(t, -1, Lyrics{Lines: lyrics.Lines}.LineAt(time.Minute))
+}
+
+func Test_app_Lyrics_of_a_local_song(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "song.lrc"), []byte(lrc), 0644))
+	a := NewApp(Preferences{}, nil, nil, Storage{})
+
+	got, err := a.Lyrics(SongRef{Connector: DirectConnector, Id: filepath.Join(dir, "song.mp3")})
+	require.NoError(t, err)
+	require.Len(t, got.Lines, 3)
+
+	got, err = a.Lyrics(SongRef{Connector: DirectConnector, Id: "file://" + filepath.Join(dir, "song.mp3")})
+	require.NoError(t, err)
+	require.Len(t, got.Lines, 3)
+
+	_, err = a.Lyrics(SongRef{Connector: DirectConnector, Id: filepath.Join(dir, "other.mp3")})
+	require.Error(t, err)
+	_, err = a.Lyrics(SongRef{Connector: DirectConnector, Id: "https://example.com/song.mp3"})
+	require.Error(t, err)
+}
@@ -120,14 +120,53 @@ func (c *connectorNhacCuaTui) Init() error {
 	return nil
 }
 
-type nctSearchResp struct {
-	Code int `json:"code"`
-	Data []struct {
+// nctSong holds the fields shared by the songs of every response
+type nctSong struct {
+	SongKey    string `json:"songKey"`
+	SongTitle  string `json:"songTitle"`
+	ArtistName string `json:"artistName"`
+	Duration   int64  `json:"duration"`
+	ArtistList []struct {
+		ArtistKey  string `json:"artistKey"`
 		ArtistName string `json:"artistName"`
-		SongTitle  string `json:"songTitle"`
-		SongKey    string `json:"songKey"`
-		Duration   int64  `json:"duration"`
-	} `json:"data"`
+	} `json:"artistList"`
+	AlbumKey   string `json:"albumKey"`
+	AlbumTitle string `json:"albumTitle"`
+	GenreName  string `json:"genreName"`
+	Thumbnail  string `json:"thumbnail"`
+	Explicit   bool   `json:"explicit"`
+	// unix time in milliseconds
+	DateRelease int64 `json:"dateRelease"`
+}
+
+func (s nctSong) toSong(connector string) Song {
+	song := Song{
+		Id:        s.SongKey,
+		Name:      s.SongTitle,
+		Artists:   s.ArtistName,
+		Duration:  utils.SecondsToDuration(s.Duration),
+		Connector: connector,
+		Thumbnail: s.Thumbnail,
+		Explicit:  s.Explicit,
+	}
+	for _, artist := range s.ArtistList {
+		song.ArtistList = append(song.ArtistList, Artist{Id: artist.ArtistKey, Name: artist.ArtistName})
+	}
+	if s.AlbumTitle != "" {
+		song.Album = &Album{Id: s.AlbumKey, Name: s.AlbumTitle}
+	}
+	if s.GenreName != "" {
+		song.Genres = []string{s.GenreName}
+	}
+	if s.DateRelease > 0 {
+		song.Year = time.Unix(0, s.DateRelease*int64(time.Millisecond)).UTC().Year()
+	}
+	return song
+}
+
+type nctSearchResp struct {
+	Code int       `json:"code"`
+	Data []nctSong `json:"data"`
 }
 
 func (c *connectorNhacCuaTui) Search(name string) ([]Song, error) {
@@ -151,13 +190,7 @@ func (c *connectorNhacCuaTui) Search(name string) ([]Song, error) {
 
 	result := make([]Song, len(decoded.Data))
 	for idx, data := range decoded.Data {
-		result[idx] = Song{
-			Id:        data.SongKey,
-			Name:      data.SongTitle,
-			Artists:   data.ArtistName,
-			Duration:  time.Duration(data.Duration) * time.Second,
-			Connector: c.Name(),
-		}
+		result[idx] = data.toSong(c.Name())
 	}
 
 	return result, nil
@@ -166,11 +199,8 @@ func (c *connectorNhacCuaTui) Search(name string) ([]Song, error) {
 type nctSongResp struct {
 	Code int `json:"code"`
 	Data struct {
-		SongKey    string `json:"songKey"`
-		SongTitle  string `json:"songTitle"`
-		ArtistName string `json:"artistName"`
-		Duration   int64  `json:"duration"`
-		StreamURL  []struct {
+		nctSong
+		StreamURL []struct {
 			Type    string `json:"type"`
 			Stream  string `json:"stream"`
 			OnlyVIP bool   `json:"onlyVIP"`
@@ -179,25 +209,15 @@ type nctSongResp struct {
 }
 
 func (c *connectorNhacCuaTui) GetStreamingUrl(id string) (StreamableSong, error) {
-	var decoded nctSongResp
-	if err := c.api(http.MethodGet, fmt.Sprintf("/v1/songs/%s", id), "", nil, &decoded); err != nil {
+	decoded, err := c.getSong(id)
+	if err != nil {
 		return StreamableSong{}, errors.Wrapf(err, "error getting streamingUrl for id=%s", id)
 	}
 
-	if decoded.Code != 0 {
-		return StreamableSong{}, errors.Errorf("got invalid response %+v", decoded)
-	}
-
 	for _, stream := range decoded.Data.StreamURL {
 		if !stream.OnlyVIP {
 			return StreamableSong{
-				Song: Song{
-					Id:        decoded.Data.SongKey,
-					Name:      decoded.Data.SongTitle,
-					Artists:   decoded.Data.ArtistName,
-					Duration:  utils.SecondsToDuration(decoded.Data.Duration),
-					Connector: c.Name(),
-				},
+				Song:         decoded.Data.toSong(c.Name()),
 				StreamingUrl: stream.Stream,
 			}, nil
 		}
@@ -205,3 +225,138 @@ func (c *connectorNhacCuaTui) GetStreamingUrl(id string) (StreamableSong, error)
 
 	return StreamableSong{}, errors.New("no playable stream has been found")
 }
+
+// GetDetails returns the details of a song, even the VIP only ones
+func (c *connectorNhacCuaTui) GetDetails(id string) (Song, error) {
+	decoded, err := c.getSong(id)
+	if err != nil {
+		return Song{}, errors.Wrapf(err, "error getting song id=%s", id)
+	}
+	return decoded.Data.toSong(c.Name()), nil
+}
+
+// getSong fetches the details and the streams of a song
+func (c *connectorNhacCuaTui) getSong(id string) (nctSongResp, error) {
+	var decoded nctSongResp
+	if err := c.api(http.MethodGet, fmt.Sprintf("/v1/songs/%s", id), "", nil, &decoded); err != nil {
+		return nctSongResp{}, err
+	}
+
+	if decoded.Code != 0 {
+		return nctSongResp{}, errors.Errorf("got invalid response %+v", decoded)
+	}
+	return decoded, nil
+}
+
+type nctPlaylistResp struct {
+	Code int `json:"code"`
+	Data struct {
+		PlaylistKey   string    `json:"playlistKey"`
+		PlaylistTitle string    `json:"playlistTitle"`
+		ListSong      []nctSong `json:"listSong"`
+	} `json:"data"`
+}
+
+func (c *connectorNhacCuaTui) GetPlaylist(id string) (Playlist, error) {
+	var decoded nctPlaylistResp
+	if err := c.api(http.MethodGet, fmt.Sprintf("/v1/playlists/%s", id), "", nil, &decoded); err != nil {
+		return Playlist{}, errors.Wrapf(err, "error getting playlist id=%s", id)
+	}
+
+	if decoded.Code != 0 {
+		return Playlist{}, errors.Errorf("got invalid response %+v", decoded)
+	}
+
+	songs := make([]Song, len(decoded.Data.ListSong))
+	for idx, data := range decoded.Data.ListSong {
+		songs[idx] = data.toSong(c.Name())
+	}
+	return Playlist{Name: decoded.Data.PlaylistTitle, Songs: songs}, nil
+}
+
+type nctLyricsResp struct {
+	Code int `json:"code"`
+	Data struct {
+		Lyric      string `json:"lyric"`
+		TimedLyric string `json:"timedLyric"`
+	} `json:"data"`
+}
+
+// GetLyrics returns the synced lyrics of a song when there is a LRC file, the plain ones otherwise
+func (c *connectorNhacCuaTui) GetLyrics(id string) (Lyrics, error) {
+	var decoded nctLyricsResp
+	if err := c.api(http.MethodGet, fmt.Sprintf("/v1/lyrics/%s", id), "", nil, &decoded); err != nil {
+		return Lyrics{}, errors.Wrapf(err, "error getting lyrics for id=%s", id)
+	}
+
+	if decoded.Code != 0 {
+		return Lyrics{}, errors.Errorf("got invalid response %+v", decoded)
+	}
+
+	if decoded.Data.TimedLyric != "" {
+		if lyrics, err := fetchLRC(c.httpClient, decoded.Data.TimedLyric); err == nil && len(lyrics.Lines) > 0 {
+			return lyrics, nil
+		}
+	}
+	if lyrics := ParsePlainLyrics(decoded.Data.Lyric); len(lyrics.Lines) > 0 {
+		return lyrics, nil
+	}
+	return Lyrics{}, errors.Errorf("song %s has no lyrics", id)
+}
+
+// nctTop20Keys maps the regions to the keys of their top 20
+var nctTop20Keys = map[string]string{
+	"vn": "nhac-viet",
+	"us": "au-my",
+	"kr": "nhac-han",
+}
+
+type nctChartResp struct {
+	Code int `json:"code"`
+	Data struct {
+		Title    string    `json:"title"`
+		ListSong []nctSong `json:"listSong"`
+	} `json:"data"`
+}
+
+// GetChart returns the weekly top 20 of a region, nct does not publish the new releases
+func (c *connectorNhacCuaTui) GetChart(kind ChartKind, region string) (Chart, error) {
+	key := nctTop20Keys[region]
+	if kind != ChartTop || key == "" {
+		return Chart{}, errors.Errorf("no %s chart for region %s", kind, region)
+	}
+
+	var decoded nctChartResp
+	if err := c.api(http.MethodGet, fmt.Sprintf("/v1/top20/%s", key), "", nil, &decoded); err != nil {
+		return Chart{}, errors.Wrapf(err, "error getting top 20 of region %s", region)
+	}
+
+	if decoded.Code != 0 {
+		return Chart{}, errors.Errorf("got invalid response %+v", decoded)
+	}
+
+	chart := Chart{Name: decoded.Data.Title, Kind: kind, Region: region, Songs: make([]Song, len(decoded.Data.ListSong))}
+	for idx, data := range decoded.Data.ListSong {
+		chart.Songs[idx] = data.toSong(c.Name())
+	}
+	return chart, nil
+}
+
+// ResolveLink recognizes urls such as https://www.nhaccuatui.com/bai-hat/name.KEY.html
+// or https://www.nhaccuatui.com/playlist/name.KEY.html
+func (c *connectorNhacCuaTui) ResolveLink(u *url.URL) (Link, bool) {
+	if !matchHost(u.Host, "nhaccuatui.com") {
+		return Link{}, false
+	}
+
+	kind, last := linkPath(u)
+	key := last[strings.LastIndex(last, ".")+1:]
+	switch kind {
+	case "bai-hat":
+		return Link{Kind: LinkSong, Connector: c.Name(), Id: key}, key != ""
+	case "playlist":
+		return Link{Kind: LinkPlaylist, Connector: c.Name(), Id: key}, key != ""
+	default:
+		return Link{}, false
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_connectorNhacCuaTui_GetPlaylist(t *testing.T) {
+	mhc := &mockHttpClient{}
+	mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet &&
+			req.URL.String() == "https://tvapi.nhaccuatui.com/v1/playlists/Gx3ZK0lCmHqK" &&
+			req.Header.Get("X-NCT-TOKEN") == "token"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body: io.NopCloser(strings.NewReader(`{"code":0,"data":{"playlistKey":"Gx3ZK0lCmHqK","playlistTitle":"Nhạc trẻ",
+			"listSong":[{"songKey":"k1","songTitle":"Lạc trôi","artistName":"Sơn Tùng M-TP","duration":233}]}}`)),
+	}, nil)
+
+	c := &connectorNhacCuaTui{httpClient: mhc, token: "token"}
+	got, err := c.GetPlaylist("Gx3ZK0lCmHqK")
+	require.NoError(t, err)
+	require.Equal(t, Playlist{Name: "Nhạc trẻ", Songs: []Song{
+		{Id: "k1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233 * time.Second, Connector: "nct"},
+	}}, got)
+	mhc.AssertExpectations(t)
+}
+
+func Test_connectorNhacCuaTui_GetLyrics(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Lyrics
+	}{
+		{"timed lyrics", `{"code":0,"data":{"lyric":"line 1<br />line 2","timedLyric":"https://lrc.nct.vn/k1.lrc"}}`,
+			Lyrics{Synced: true, Lines: []LyricsLine{{Time: time.Second, Text: "line 1"}, {Time: 2 * time.Second, Text: "line 2"}}}},
+		{"plain lyrics", `{"code":0,"data":{"lyric":"line 1<br />line 2","timedLyric":""}}`,
+			Lyrics{Lines: []LyricsLine{{Text: "line 1"}, {Text: "line 2"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mhc := &mockHttpClient{}
+			mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.URL.String() == "https://tvapi.nhaccuatui.com/v1/lyrics/k1"
+			})).Return(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(tt.body))}, nil)
+			mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.URL.String() == "https://lrc.nct.vn/k1.lrc"
+			})).Return(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("[00:01.00]line 1\n[00:02.00]line 2"))}, nil).Maybe()
+
+			c := &connectorNhacCuaTui{httpClient: mhc, token: "token"}
+			got, err := c.GetLyrics("k1")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			mhc.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_connectorNhacCuaTui_GetChart(t *testing.T) {
+	mhc := &mockHttpClient{}
+	mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && req.URL.String() == "https://tvapi.nhaccuatui.com/v1/top20/au-my"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body: io.NopCloser(strings.NewReader(`{"code":0,"data":{"title":"Top 20 Âu Mỹ",
+			"listSong":[{"songKey":"k1","songTitle":"Shape of You","artistName":"Ed Sheeran","duration":233}]}}`)),
+	}, nil)
+
+	c := &connectorNhacCuaTui{httpClient: mhc, token: "token"}
+	got, err := c.GetChart(ChartTop, "us")
+	require.NoError(t, err)
+	require.Equal(t, Chart{Name: "Top 20 Âu Mỹ", Kind: ChartTop, Region: "us", Songs: []Song{
+		{Id: "k1", Name: "Shape of You", Artists: "Ed Sheeran", Duration: 233 * time.Second, Connector: "nct"},
+	}}, got)
+	mhc.AssertExpectations(t)
+
+	_, err = c.GetChart(ChartNewReleases, "vn")
+	require.Error(t, err)
+}
+
+func Test_connectorNhacCuaTui_GetDetails_of_VIP_song(t *testing.T) {
+	body := `{"code":0,"data":{"songKey":"k1","songTitle":"Lạc trôi","artistName":"Sơn Tùng M-TP",
+		"duration":233,"streamURL":[{"type":"320","stream":"https://stream","onlyVIP":true}]}}`
+	mhc := &mockHttpClient{}
+	for i := 0; i < 2; i++ {
+		mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "https://tvapi.nhaccuatui.com/v1/songs/k1"
+		})).Return(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil).Once()
+	}
+
+	c := &connectorNhacCuaTui{httpClient: mhc, token: "token"}
+	_, err := c.GetStreamingUrl("k1")
+	require.Error(t, err)
+
+	got, err := c.GetDetails("k1")
+	require.NoError(t, err)
+	require.Equal(t, Song{Id: "k1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233 * time.Second, Connector: "nct"}, got)
+	mhc.AssertExpectations(t)
+}
+
+func Test_nctSong_toSong(t *testing.T) {
+	var resp nctSearchResp
+	require.NoError(t, json.Unmarshal([]byte(`{"code":0,"data":[{"songKey":"k1","songTitle":"Lạc trôi",
+		"artistName":"Sơn Tùng M-TP","duration":233,"artistList":[{"artistKey":"a1","artistName":"Sơn Tùng M-TP"}],
+		"albumKey":"al1","albumTitle":"Lạc trôi (Single)","genreName":"Nhạc Trẻ","thumbnail":"https://nct/t.jpg",
+		"dateRelease":1483462800000}]}`), &resp))
+
+	require.Equal(t, Song{
+		Id:         "k1",
+		Name:       "Lạc trôi",
+		Artists:    "Sơn Tùng M-TP",
+		Duration:   233 * time.Second,
+		Connector:  "nct",
+		ArtistList: []Artist{{Id: "a1", Name: "Sơn Tùng M-TP"}},
+		Album:      &Album{Id: "al1", Name: "Lạc trôi (Single)"},
+		Year:       2017,
+		Genres:     []string{"Nhạc Trẻ"},
+		Thumbnail:  "https://nct/t.jpg",
+	}, resp.Data[0].toSong("nct"))
+}
@@ -2,14 +2,26 @@ package domain
 
 import (
 	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
 	"github.com/faiface/beep/mp3"
-	"github.com/faiface/beep/speaker"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"io"
 	"io.github.binatory/budich-cli/internal/domain/musicstream"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"sync"
 	"time"
 )
 
+const (
+	MinVolume     = 0
+	MaxVolume     = 100
+	DefaultVolume = MaxVolume
+)
+
 type State string
 
 const (
@@ -21,18 +33,37 @@ const (
 	StateStopped              = "StateStopped"
 )
 
+// Segment is the part of a song between Start and End, it is empty when End is not after Start
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+func (s Segment) IsEmpty() bool {
+	return s.End <= s.Start
+}
+
 type PlayerStatus struct {
-	Song  StreamableSong
-	State State
-	Err   error
-	Pos   time.Duration
-	Len   time.Duration
+	Song   StreamableSong
+	State  State
+	Err    error
+	Pos    time.Duration
+	Len    time.Duration
+	Loop   Segment
+	Volume int
+
+	// time actually spent playing, excluding loading and pauses
+	Listened time.Duration
 }
 
 type Player interface {
 	Start() error
 	PauseOrResume()
 	Stop()
+	FadeOut(d time.Duration)
+	Seek(pos time.Duration) error
+	SetLoop(loop Segment) error
+	SetVolume(percent int)
 	Report() PlayerStatus
 }
 
@@ -40,43 +71,48 @@ type player struct {
 	state    State
 	err      error
 	song     StreamableSong
+	sink     Sink
 	done     chan struct{}
 	streamer beep.StreamSeekCloser
 	format   *beep.Format
 	ctrl     *beep.Ctrl
-}
+	fader    *fader
+	looper   *looper
+	gain     *effects.Volume
+	seekable bool
+	listened stopwatch
 
-const systemSampleRate = beep.SampleRate(48000)
-
-func init() {
-	if err := speaker.Init(systemSampleRate, systemSampleRate.N(time.Second/10)); err != nil {
-		panic(err)
-	}
+	// applied once the stream is ready when set before
+	startAt time.Duration
+	loop    Segment
+	volume  int
 }
 
-func NewPlayer(song StreamableSong) Player {
+func NewPlayer(song StreamableSong, sink Sink) Player {
 	return &player{
-		StateNotInitialized,
-		nil,
-		song,
-		make(chan struct{}),
-		nil, nil, nil,
+		state:  StateNotInitialized,
+		song:   song,
+		sink:   sink,
+		done:   make(chan struct{}),
+		volume: DefaultVolume,
 	}
 }
 
 func (p *player) Start() (err error) {
 	defer func() {
 		if err != nil {
+			p.sink.Lock()
 			p.err = err
 			p.state = StateError
+			p.sink.Unlock()
 		}
 	}()
 
 	// switch state to StateLoading
-	p.state = StateLoading
+	p.setState(StateLoading)
 
 	// create a stream
-	ms, err := musicstream.New(p.song.StreamingUrl, http.DefaultClient)
+	ms, err := openStream(p.song.StreamingUrl)
 	if err != nil {
 		err = errors.Wrapf(err, "error creating music stream url=%s", p.song.StreamingUrl)
 		return
@@ -92,18 +128,43 @@ func (p *player) Start() (err error) {
 	defer streamer.Close()
 
 	// create beep streamers
-	resampled := beep.Resample(4, format.SampleRate, systemSampleRate, streamer)
+	looper := &looper{StreamSeeker: streamer}
+	resampled := beep.Resample(4, format.SampleRate, p.sink.SampleRate(), looper)
 	ctrl := &beep.Ctrl{Streamer: resampled, Paused: false}
+	gain := &effects.Volume{Streamer: ctrl, Base: 2}
+	fader := &fader{Streamer: gain, left: -1}
 
-	// mutate the player
+	// mutate the player then apply the position, loop and volume requested before
+	p.sink.Lock()
 	p.streamer, p.format = streamer, &format
-	p.ctrl = ctrl
-	p.state = StatePlaying
+	p.ctrl, p.fader, p.looper, p.gain = ctrl, fader, looper, gain
+	p.applyVolume()
+	_, p.seekable = ms.(io.Seeker)
+	if p.seekable {
+		if !p.loop.IsEmpty() {
+			looper.start, looper.end = p.samples(p.loop.Start), p.samples(p.loop.End)
+		}
+		if p.startAt > 0 {
+			err = streamer.Seek(p.samples(p.startAt))
+		}
+	} else if p.startAt > 0 {
+		log.Warn().Msgf("song %s is not seekable, playing it from the start rather than at %s", p.song.Ref(), p.startAt)
+	}
+	p.sink.Unlock()
+	if err != nil {
+		err = errors.Wrapf(err, "error seeking song to %s", p.startAt)
+		return
+	}
+	p.setState(StatePlaying)
+	p.listened.Start()
 
-	// start playing
-	speaker.Play(beep.Seq(ctrl, beep.Callback(func() {
-		p.Stop()
-	})))
+	// start playing, the sink streams the callback with itself locked
+	if err = p.sink.Play(pausable{Streamer: beep.Seq(fader, beep.Callback(func() {
+		p.stop()
+	})), ctrl: ctrl}); err != nil {
+		err = errors.Wrap(err, "error playing song")
+		return
+	}
 
 	// wait until playing done
 	for {
@@ -114,19 +175,46 @@ func (p *player) Start() (err error) {
 	}
 }
 
+// openStream opens a remote stream, or a local file when the url is not http(s)
+func openStream(streamingUrl string) (io.ReadCloser, error) {
+	u, err := url.Parse(streamingUrl)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return musicstream.New(streamingUrl, http.DefaultClient)
+	}
+
+	path := streamingUrl
+	if err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	return os.Open(path)
+}
+
 func (p *player) PauseOrResume() {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
 	if p.ctrl != nil {
 		p.ctrl.Paused = !p.ctrl.Paused
 
 		if p.ctrl.Paused {
 			p.state = StatePaused
+			p.listened.Stop()
 		} else {
 			p.state = StatePlaying
+			p.listened.Start()
 		}
 	}
 }
 
 func (p *player) Stop() {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	p.stop()
+}
+
+// stop must be called with the sink locked
+func (p *player) stop() {
 	if p.ctrl != nil {
 		p.ctrl.Streamer = nil // stop playing
 	}
@@ -141,17 +229,234 @@ func (p *player) Stop() {
 
 	// set state
 	p.state = StateStopped
+	p.listened.Stop()
+}
+
+// setState locks the sink as Report reads the state under its lock
+func (p *player) setState(state State) {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	p.state = state
+}
+
+// FadeOut lowers the volume down to silence over d then stops the player
+func (p *player) FadeOut(d time.Duration) {
+	if p.fader == nil {
+		p.Stop()
+		return
+	}
+
+	p.sink.Lock()
+	defer p.sink.Unlock()
+	p.fader.total = p.sink.SampleRate().N(d)
+	p.fader.left = p.fader.total
+}
+
+// Seek moves the playback to pos, or makes it start there when the stream is not ready yet
+func (p *player) Seek(pos time.Duration) error {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	if p.streamer == nil {
+		p.startAt = pos
+		return nil
+	}
+	if !p.seekable {
+		return errors.New("song is not seekable")
+	}
+	return errors.Wrapf(p.streamer.Seek(p.samples(pos)), "error seeking song to %s", pos)
+}
+
+// SetLoop repeats the loop segment until another one is set, an empty segment stops looping
+func (p *player) SetLoop(loop Segment) error {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	if p.looper == nil {
+		p.loop = loop
+		return nil
+	}
+	if !p.seekable && !loop.IsEmpty() {
+		return errors.New("song is not seekable")
+	}
+
+	if loop.IsEmpty() {
+		p.looper.start, p.looper.end = 0, 0
+		p.loop = loop
+		return nil
+	}
+
+	start, end := p.samples(loop.Start), p.samples(loop.End)
+	if start >= end {
+		return errors.Errorf("loop %s-%s is out of the song bounds", loop.Start, loop.End)
+	}
+	p.looper.start, p.looper.end = start, end
+	p.loop = loop
+	return nil
+}
+
+// SetVolume sets the volume between MinVolume and MaxVolume percents
+func (p *player) SetVolume(percent int) {
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	p.volume = ClampVolume(percent)
+	if p.gain != nil {
+		p.applyVolume()
+	}
+}
+
+// applyVolume must be called with the sink locked
+func (p *player) applyVolume() {
+	p.gain.Silent = p.volume <= MinVolume
+	p.gain.Volume = math.Log2(float64(p.volume) / MaxVolume)
+}
+
+func ClampVolume(percent int) int {
+	if percent < MinVolume {
+		return MinVolume
+	}
+	if percent > MaxVolume {
+		return MaxVolume
+	}
+	return percent
+}
+
+// samples converts d to a number of samples within the song bounds, it must be called with the sink locked
+func (p *player) samples(d time.Duration) int {
+	n := p.format.SampleRate.N(d)
+	if n < 0 {
+		return 0
+	}
+	if n > p.streamer.Len() {
+		return p.streamer.Len()
+	}
+	return n
 }
 
 func (p *player) Report() PlayerStatus {
-	status := PlayerStatus{Song: p.song, State: p.state, Err: p.err}
+	p.sink.Lock()
+	defer p.sink.Unlock()
+
+	status := PlayerStatus{Song: p.song, State: p.state, Err: p.err, Loop: p.loop, Volume: p.volume, Listened: p.listened.Elapsed()}
 	if p.format == nil || p.streamer == nil {
 		return status
 	}
 
-	speaker.Lock()
-	defer speaker.Unlock()
 	status.Pos = p.format.SampleRate.D(p.streamer.Position()).Round(time.Second)
 	status.Len = p.format.SampleRate.D(p.streamer.Len()).Round(time.Second)
 	return status
 }
+
+// pausable is the streamer a player hands to its sink, telling the sink whether the player is paused
+type pausable struct {
+	beep.Streamer
+	ctrl *beep.Ctrl
+}
+
+func (p pausable) Paused() bool {
+	return p.ctrl.Paused
+}
+
+// fader linearly fades its streamer out once left is set, then drains.
+// A negative left means no fading is in progress.
+type fader struct {
+	beep.Streamer
+	total int
+	left  int
+}
+
+func (f *fader) Stream(samples [][2]float64) (int, bool) {
+	if f.left == 0 {
+		return 0, false
+	}
+
+	n, ok := f.Streamer.Stream(samples)
+	if f.left < 0 {
+		return n, ok
+	}
+
+	if n > f.left {
+		n = f.left
+	}
+	for i := range samples[:n] {
+		gain := float64(f.left) / float64(f.total)
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+		f.left--
+	}
+	return n, ok
+}
+
+// looper repeats the samples between start and end of its streamer,
+// looping is disabled when end is not positive
+type looper struct {
+	beep.StreamSeeker
+	start int
+	end   int
+}
+
+func (l *looper) Stream(samples [][2]float64) (n int, ok bool) {
+	if l.end <= 0 {
+		return l.StreamSeeker.Stream(samples)
+	}
+
+	for n < len(samples) {
+		if l.Position() >= l.end {
+			if err := l.Seek(l.start); err != nil {
+				// give up looping rather than interrupting the song
+				l.start, l.end = 0, 0
+				sn, sok := l.StreamSeeker.Stream(samples[n:])
+				return n + sn, sok || n > 0
+			}
+		}
+
+		chunk := samples[n:]
+		if left := l.end - l.Position(); left < len(chunk) {
+			chunk = chunk[:left]
+		}
+		sn, sok := l.StreamSeeker.Stream(chunk)
+		n += sn
+		if !sok || sn == 0 {
+			return n, n > 0
+		}
+	}
+	return n, true
+}
+
+// stopwatch sums up the time elapsed between its starts and stops
+type stopwatch struct {
+	sync.Mutex
+	elapsed time.Duration
+	since   time.Time // zero when stopped
+}
+
+func (sw *stopwatch) Start() {
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.since.IsZero() {
+		sw.since = time.Now()
+	}
+}
+
+func (sw *stopwatch) Stop() {
+	sw.Lock()
+	defer sw.Unlock()
+
+	if !sw.since.IsZero() {
+		sw.elapsed += time.Since(sw.since)
+		sw.since = time.Time{}
+	}
+}
+
+func (sw *stopwatch) Elapsed() time.Duration {
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.since.IsZero() {
+		return sw.elapsed
+	}
+	return sw.elapsed + time.Since(sw.since)
+}
@@ -0,0 +1,299 @@
+package domain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Preferences are the settings of the user, see Config for where they come from
+type Preferences struct {
+	HttpTimeout      time.Duration
+	LogPath          string // where the logs go while the TUI is running
+	SampleRate       beep.SampleRate
+	Sink             string // spec of the audio output, see NewSink
+	DefaultConnector string
+	ReleasesOnly     bool // ignore the pre-releases when checking for updates
+}
+
+func DefaultPreferences(dataDir string) Preferences {
+	return Preferences{
+		HttpTimeout:      30 * time.Second,
+		LogPath:          filepath.Join(dataDir, "output.log"),
+		SampleRate:       defaultSampleRate,
+		Sink:             SinkSpeaker,
+		DefaultConnector: "zmp3",
+		ReleasesOnly:     true,
+	}
+}
+
+// ConfigSource tells where the value of a setting comes from
+type ConfigSource string
+
+const (
+	ConfigDefault ConfigSource = "default"
+	ConfigFile    ConfigSource = "file"
+	ConfigEnv     ConfigSource = "env"
+)
+
+type ConfigEntry struct {
+	Key         string
+	Value       string
+	Source      ConfigSource
+	Env         string // the environment variable overriding the setting
+	Description string
+}
+
+type setting struct {
+	key         string
+	env         string
+	description string
+	get         func(p Preferences) string
+	set         func(p *Preferences, value string) error
+}
+
+var settings = []setting{
+	{"httpTimeout", "BD_HTTP_TIMEOUT", "timeout of the http requests, e.g. 30s",
+		func(p Preferences) string { return p.HttpTimeout.String() },
+		func(p *Preferences, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return errors.Errorf("invalid duration %s, e.g. 30s", value)
+			}
+			p.HttpTimeout = d
+			return nil
+		}},
+	{"logPath", "BD_LOG_PATH", "file the logs are written to while the TUI is running",
+		func(p Preferences) string { return p.LogPath },
+		func(p *Preferences, value string) error {
+			if value == "" {
+				return errors.New("the log path must not be empty")
+			}
+			p.LogPath = value
+			return nil
+		}},
+	{"sampleRate", "BD_SAMPLE_RATE", "sample rate of the audio output in Hz, e.g. 44100",
+		func(p Preferences) string { return strconv.Itoa(int(p.SampleRate)) },
+		func(p *Preferences, value string) error {
+			rate, err := strconv.Atoi(value)
+			if err != nil || rate < 8000 || rate > 192000 {
+				return errors.Errorf("invalid sample rate %s, expected from 8000 to 192000", value)
+			}
+			p.SampleRate = beep.SampleRate(rate)
+			return nil
+		}},
+	{"sink", "BD_SINK", "audio output: speaker, null or wav:<path>",
+		func(p Preferences) string { return p.Sink },
+		func(p *Preferences, value string) error {
+			if _, _, err := parseSinkSpec(value); err != nil {
+				return err
+			}
+			p.Sink = value
+			return nil
+		}},
+	{"defaultConnector", "BD_DEFAULT_CONNECTOR", "connector used when none is given",
+		func(p Preferences) string { return p.DefaultConnector },
+		func(p *Preferences, value string) error {
+			if value == "" {
+				return errors.New("the default connector must not be empty")
+			}
+			p.DefaultConnector = value
+			return nil
+		}},
+	{"releasesOnly", "BD_RELEASES_ONLY", "ignore the pre-releases when checking for updates",
+		func(p Preferences) string { return strconv.FormatBool(p.ReleasesOnly) },
+		func(p *Preferences, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.Errorf("invalid boolean %s, expected true or false", value)
+			}
+			p.ReleasesOnly = b
+			return nil
+		}},
+}
+
+func findSetting(key string) (setting, error) {
+	keys := make([]string, len(settings))
+	for idx, s := range settings {
+		if s.key == key {
+			return s, nil
+		}
+		keys[idx] = s.key
+	}
+	return setting{}, errors.Errorf("unknown setting %s, expected one of %s", key, strings.Join(keys, ", "))
+}
+
+// Config reads the preferences from a YAML file, the environment variables override it
+// and the defaults apply to the settings missing from both
+type Config interface {
+	Path() string
+	Preferences() (Preferences, error)
+	Get(key string) (ConfigEntry, error)
+	// Set writes a setting to the file, keeping the others and the comments
+	Set(key, value string) error
+	List() ([]ConfigEntry, error)
+}
+
+type config struct {
+	path     string
+	defaults Preferences
+	getenv   func(string) string
+}
+
+func NewConfig(path string, defaults Preferences, getenv func(string) string) Config {
+	return &config{path: path, defaults: defaults, getenv: getenv}
+}
+
+func (c *config) Path() string {
+	return c.path
+}
+
+func (c *config) Preferences() (Preferences, error) {
+	prefs, _, err := c.load()
+	return prefs, err
+}
+
+func (c *config) Get(key string) (ConfigEntry, error) {
+	if _, err := findSetting(key); err != nil {
+		return ConfigEntry{}, err
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		return ConfigEntry{}, err
+	}
+	for _, e := range entries {
+		if e.Key == key {
+			return e, nil
+		}
+	}
+	return ConfigEntry{}, errors.Errorf("unknown setting %s", key)
+}
+
+func (c *config) List() ([]ConfigEntry, error) {
+	_, entries, err := c.load()
+	return entries, err
+}
+
+func (c *config) load() (Preferences, []ConfigEntry, error) {
+	doc, err := c.read()
+	if err != nil {
+		return Preferences{}, nil, err
+	}
+	values, err := mappingValues(doc.Content[0])
+	if err != nil {
+		return Preferences{}, nil, errors.Wrapf(err, "error reading %s", c.path)
+	}
+	for key := range values {
+		if _, err := findSetting(key); err != nil {
+			return Preferences{}, nil, errors.Wrapf(err, "error reading %s", c.path)
+		}
+	}
+
+	prefs := c.defaults
+	entries := make([]ConfigEntry, len(settings))
+	for idx, s := range settings {
+		source := ConfigDefault
+		if value, found := values[s.key]; found {
+			if err := s.set(&prefs, value); err != nil {
+				return Preferences{}, nil, errors.Wrapf(err, "invalid %s in %s", s.key, c.path)
+			}
+			source = ConfigFile
+		}
+		if value := c.getenv(s.env); value != "" {
+			if err := s.set(&prefs, value); err != nil {
+				return Preferences{}, nil, errors.Wrapf(err, "invalid %s", s.env)
+			}
+			source = ConfigEnv
+		}
+		entries[idx] = ConfigEntry{Key: s.key, Value: s.get(prefs), Source: source, Env: s.env, Description: s.description}
+	}
+	return prefs, entries, nil
+}
+
+func (c *config) Set(key, value string) error {
+	s, err := findSetting(key)
+	if err != nil {
+		return err
+	}
+	// the value is checked alone so that a setting can be fixed in a file having other invalid ones
+	prefs := c.defaults
+	if err := s.set(&prefs, value); err != nil {
+		return err
+	}
+
+	doc, err := c.read()
+	if err != nil {
+		return err
+	}
+	mapping := doc.Content[0]
+	found := false
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			mapping.Content[idx+1] = &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+			found = true
+		}
+	}
+	if !found {
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value},
+		)
+	}
+	return c.write(doc)
+}
+
+// read parses the file into a document holding a mapping, an empty one when the file does not exist
+func (c *config) read() (*yaml.Node, error) {
+	var doc yaml.Node
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "error reading %s", c.path)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrapf(err, "error decoding %s", c.path)
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.Errorf("error decoding %s, expected a mapping of the settings", c.path)
+	}
+	return &doc, nil
+}
+
+func (c *config) write(doc *yaml.Node) error {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return errors.Wrapf(err, "error encoding %s", c.path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory of %s", c.path)
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrapf(err, "error writing %s", tmp)
+	}
+	return errors.Wrapf(os.Rename(tmp, c.path), "error replacing %s", c.path)
+}
+
+func mappingValues(mapping *yaml.Node) (map[string]string, error) {
+	values := make(map[string]string, len(mapping.Content)/2)
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		key, value := mapping.Content[idx], mapping.Content[idx+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, errors.Errorf("the value of %s at line %d is not a scalar", key.Value, value.Line)
+		}
+		values[key.Value] = value.Value
+	}
+	return values, nil
+}
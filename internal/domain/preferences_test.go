@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	env := map[string]string{}
+	config := NewConfig(path, DefaultPreferences(dir), func(key string) string {
+		return env[key]
+	})
+
+	prefs, err := config.Preferences()
+	require.NoError(t, err)
+	require.Equal(t, DefaultPreferences(dir), prefs)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("# tuned for my laptop\nhttpTimeout: 10s\nsampleRate: 44100\n"), 0644))
+	require.NoError(t, config.Set("defaultConnector", "nct"))
+	require.NoError(t, config.Set("httpTimeout", "1m"))
+	require.EqualError(t, config.Set("releasesOnly", "maybe"), "invalid boolean maybe, expected true or false")
+	require.EqualError(t, config.Set("toto", "1"), "unknown setting toto, expected one of httpTimeout, logPath, sampleRate, sink, defaultConnector, releasesOnly")
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "# tuned for my laptop\nhttpTimeout: 1m\nsampleRate: 44100\ndefaultConnector: nct\n", string(data))
+
+	env["BD_SINK"] = "null"
+	prefs, err = config.Preferences()
+	require.NoError(t, err)
+	require.Equal(t, Preferences{
+		HttpTimeout:      time.Minute,
+		LogPath:          filepath.Join(dir, "output.log"),
+		SampleRate:       beep.SampleRate(44100),
+		Sink:             SinkNull,
+		DefaultConnector: "nct",
+		ReleasesOnly:     true,
+	}, prefs)
+
+	entry, err := config.Get("sink")
+	require.NoError(t, err)
+	require.Equal(t, ConfigEntry{Key: "sink", Value: "null", Source: ConfigEnv, Env: "BD_SINK", Description: "audio output: speaker, null or wav:<path>"}, entry)
+	entry, err = config.Get("sampleRate")
+	require.NoError(t, err)
+	require.Equal(t, ConfigFile, entry.Source)
+	entry, err = config.Get("releasesOnly")
+	require.NoError(t, err)
+	require.Equal(t, ConfigDefault, entry.Source)
+
+	env["BD_SAMPLE_RATE"] = "fast"
+	_, err = config.Preferences()
+	require.EqualError(t, err, "invalid BD_SAMPLE_RATE: invalid sample rate fast, expected from 8000 to 192000")
+	delete(env, "BD_SAMPLE_RATE")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("sink: toto\n"), 0644))
+	_, err = config.Preferences()
+	require.EqualError(t, err, "invalid sink in "+path+": sink toto not recognized")
+	// an invalid setting can be fixed with set
+	require.NoError(t, config.Set("sink", "speaker"))
+	_, err = config.Preferences()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, []byte("volume: 50\n"), 0644))
+	_, err = config.Preferences()
+	require.EqualError(t, err, "error reading "+path+": unknown setting volume, expected one of httpTimeout, logPath, sampleRate, sink, defaultConnector, releasesOnly")
+	require.NoError(t, ioutil.WriteFile(path, []byte("- httpTimeout\n"), 0644))
+	_, err = config.Preferences()
+	require.EqualError(t, err, "error decoding "+path+", expected a mapping of the settings")
+}
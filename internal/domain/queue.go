@@ -0,0 +1,574 @@
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type SleepMode string
+
+const (
+	SleepOff       SleepMode = "SleepOff"
+	SleepAfterTime SleepMode = "SleepAfterTime"
+	SleepAfterSong SleepMode = "SleepAfterSong"
+)
+
+const sleepFadeOut = 10 * time.Second
+
+const (
+	// number of recommended songs added at once when the queue runs out
+	autoplayBatch = 5
+	// number of the most recent history entries autoplay does not repeat
+	autoplayRecentHistory = 50
+)
+
+type SleepTimer struct {
+	Mode  SleepMode
+	After time.Duration
+}
+
+type SleepStatus struct {
+	SleepTimer
+	Remaining time.Duration
+}
+
+// ParseSleepTimer accepts either a duration (e.g. 30m) or "song" to stop after the current song
+func ParseSleepTimer(s string) (SleepTimer, error) {
+	switch s {
+	case "", "off":
+		return SleepTimer{Mode: SleepOff}, nil
+	case "song":
+		return SleepTimer{Mode: SleepAfterSong}, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return SleepTimer{}, errors.Errorf("invalid sleep timer %s, expected a positive duration or \"song\"", s)
+	}
+	return SleepTimer{Mode: SleepAfterTime, After: d}, nil
+}
+
+type QueueStatus struct {
+	Player  PlayerStatus
+	Index   int // index of the next or current song
+	Playing int // index of the song owning Player, -1 if none
+	Len     int
+	Running bool
+	Volume  int
+	Sleep   SleepStatus
+
+	// Player plays the song from another source than the queued one, which failed
+	Alternative bool
+	// songs related to the last one are queued when the queue runs out
+	Autoplay bool
+}
+
+type Queue interface {
+	Add(songs ...Song)
+	AddGroups(groups ...SongGroup)
+	Songs() []Song
+	Play(index int) error
+	Next()
+	Prev()
+	PauseOrResume()
+	Stop()
+	Seek(pos time.Duration) error
+	SetLoop(loop Segment) error
+	SetVolume(percent int)
+	Sleep(timer SleepTimer)
+	SetAutoplay(on bool)
+	Report() QueueStatus
+	Wait() error
+	Snapshot() Session
+	Restore(session Session)
+}
+
+type queue struct {
+	sync.RWMutex
+	app     App
+	songs   []Song
+	index   int
+	playing int
+	current Player
+	// current plays an alternative of the queued song
+	alternative bool
+	jumped      bool
+	stopped     bool
+	running     bool
+	done        chan struct{}
+	err         error
+	volume      int
+	autoplay    bool
+
+	// the same songs from other sources found along with the queued ones, by index
+	alternatives map[int][]Song
+
+	// applied to the next player when requested while none is active
+	pendingSeek time.Duration
+	pendingLoop Segment
+
+	sleep         SleepTimer
+	sleepDeadline time.Time
+	sleepTimer    *time.Timer
+	sleepFired    bool
+}
+
+func NewQueue(app App) Queue {
+	return &queue{app: app, playing: -1, volume: DefaultVolume, sleep: SleepTimer{Mode: SleepOff}}
+}
+
+func (q *queue) Add(songs ...Song) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.songs = append(q.songs, songs...)
+}
+
+// AddGroups queues the songs of groups, their alternatives are played when they fail
+func (q *queue) AddGroups(groups ...SongGroup) {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, g := range groups {
+		if len(g.Alternatives) > 0 {
+			if q.alternatives == nil {
+				q.alternatives = make(map[int][]Song)
+			}
+			q.alternatives[len(q.songs)] = g.Alternatives
+		}
+		q.songs = append(q.songs, g.Song)
+	}
+}
+
+func (q *queue) Songs() []Song {
+	q.RLock()
+	defer q.RUnlock()
+
+	return append([]Song(nil), q.songs...)
+}
+
+// Play jumps to the song at index and makes sure the queue is playing
+func (q *queue) Play(index int) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if index < 0 || index >= len(q.songs) {
+		return errors.Errorf("index %d out of queue bounds [0, %d)", index, len(q.songs))
+	}
+
+	q.jumpTo(index)
+	if !q.running {
+		q.running, q.err = true, nil
+		q.done = make(chan struct{})
+		go q.run()
+	}
+	return nil
+}
+
+func (q *queue) Next() {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.running && q.index+1 < len(q.songs) {
+		q.jumpTo(q.index + 1)
+	}
+}
+
+func (q *queue) Prev() {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.running && q.index > 0 {
+		q.jumpTo(q.index - 1)
+	}
+}
+
+// jumpTo must be called with the lock held
+func (q *queue) jumpTo(index int) {
+	if index != q.index {
+		q.pendingSeek, q.pendingLoop = 0, Segment{}
+	}
+	q.index = index
+	q.stopped = false
+	q.jumped = true
+	if q.current != nil {
+		skip(q.current)
+	}
+}
+
+// skipper is implemented by the players which tell a skipped song from a stopped one
+type skipper interface {
+	Skip()
+}
+
+// skip stops player because the user moved to another song
+func skip(player Player) {
+	if s, ok := player.(skipper); ok {
+		s.Skip()
+		return
+	}
+	player.Stop()
+}
+
+func (q *queue) PauseOrResume() {
+	q.RLock()
+	defer q.RUnlock()
+
+	if q.current != nil {
+		q.current.PauseOrResume()
+	}
+}
+
+func (q *queue) Stop() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.stopLocked()
+}
+
+// stopLocked must be called with the lock held
+func (q *queue) stopLocked() {
+	q.stopped = true
+	if q.current != nil {
+		q.current.Stop()
+	}
+}
+
+// Seek moves the current song to pos, or makes the next song start there if none is playing
+func (q *queue) Seek(pos time.Duration) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.current != nil && !q.jumped {
+		return q.current.Seek(pos)
+	}
+	q.pendingSeek = pos
+	return nil
+}
+
+// SetLoop loops over a segment of the current song, or of the next song if none is playing
+func (q *queue) SetLoop(loop Segment) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.current != nil && !q.jumped {
+		return q.current.SetLoop(loop)
+	}
+	q.pendingLoop = loop
+	return nil
+}
+
+// SetVolume sets the volume of the current and next songs
+func (q *queue) SetVolume(percent int) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.volume = ClampVolume(percent)
+	if q.current != nil {
+		q.current.SetVolume(q.volume)
+	}
+}
+
+func (q *queue) Sleep(timer SleepTimer) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.sleepTimer != nil {
+		q.sleepTimer.Stop()
+		q.sleepTimer = nil
+	}
+	q.sleep, q.sleepFired = timer, false
+
+	if timer.Mode != SleepAfterTime {
+		return
+	}
+
+	fade := sleepFadeOut
+	if fade > timer.After {
+		fade = timer.After
+	}
+	q.sleepDeadline = time.Now().Add(timer.After)
+	q.sleepTimer = time.AfterFunc(timer.After-fade, func() {
+		q.Lock()
+		defer q.Unlock()
+
+		q.sleepFired = true
+		if q.current == nil || q.current.Report().State != StatePlaying {
+			q.stopLocked()
+			return
+		}
+		q.current.FadeOut(fade)
+	})
+}
+
+func (q *queue) SetAutoplay(on bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.autoplay = on
+}
+
+func (q *queue) Report() QueueStatus {
+	q.RLock()
+	defer q.RUnlock()
+
+	status := QueueStatus{
+		Index:    q.index,
+		Playing:  q.playing,
+		Len:      len(q.songs),
+		Running:  q.running,
+		Volume:   q.volume,
+		Sleep:    SleepStatus{SleepTimer: q.sleep},
+		Autoplay: q.autoplay,
+	}
+	if q.sleep.Mode == SleepAfterTime {
+		status.Sleep.Remaining = time.Until(q.sleepDeadline).Round(time.Second)
+	}
+	if q.current != nil {
+		status.Player = q.current.Report()
+		status.Alternative = q.alternative
+	}
+	return status
+}
+
+// Wait blocks until the queue stops playing then returns the last playback error
+func (q *queue) Wait() error {
+	q.RLock()
+	done := q.done
+	q.RUnlock()
+
+	if done == nil {
+		return nil
+	}
+	<-done
+
+	q.RLock()
+	defer q.RUnlock()
+	return q.err
+}
+
+func (q *queue) run() {
+	var lastErr error
+	defer func() {
+		q.Lock()
+		q.running, q.err = false, lastErr
+		close(q.done)
+		q.Unlock()
+	}()
+
+	for {
+		q.Lock()
+		if !q.stopped && q.autoplay && q.index >= len(q.songs) && len(q.songs) > 0 {
+			last := q.songs[len(q.songs)-1]
+			q.Unlock()
+			q.autoplayAfter(last)
+			q.Lock()
+		}
+		if q.stopped || q.index >= len(q.songs) {
+			q.Unlock()
+			return
+		}
+		index, song := q.index, q.songs[q.index]
+		q.current, q.playing, q.alternative = nil, -1, false
+		q.jumped = false
+		q.Unlock()
+
+		err := q.playSong(index, song, false)
+		if err != nil {
+			err = q.failover(index, song, err)
+		}
+		if err != nil {
+			lastErr = err
+		}
+
+		q.Lock()
+		if q.sleepFired || (q.sleep.Mode == SleepAfterSong && !q.jumped) {
+			q.sleep, q.sleepFired = SleepTimer{Mode: SleepOff}, false
+			q.stopped = true
+		}
+		if !q.jumped && !q.stopped {
+			q.index++
+		}
+		q.Unlock()
+	}
+}
+
+// playSong plays song as the one at index until it is done, alternative tells whether it replaces the queued one
+func (q *queue) playSong(index int, song Song, alternative bool) error {
+	player, err := q.app.Play(song.Ref())
+	if err != nil {
+		return err
+	}
+
+	q.Lock()
+	if q.jumped || q.stopped {
+		q.Unlock()
+		return nil
+	}
+	q.current, q.playing, q.alternative = player, index, alternative
+	err = q.applyPending(player)
+	q.Unlock()
+	if err != nil {
+		return err
+	}
+	return player.Start()
+}
+
+// hasStarted tells whether the current song has been played at all, or has been left by the user
+func (q *queue) hasStarted() bool {
+	q.RLock()
+	defer q.RUnlock()
+	return q.jumped || q.stopped || (q.current != nil && q.current.Report().Listened > 0)
+}
+
+// failover plays the same song from other sources when it cannot be played at all,
+// e.g. because its streaming url cannot be fetched or the stream is forbidden for VIP only songs.
+// The alternatives queued along with the song are tried first, then the ones found on the other connectors
+func (q *queue) failover(index int, song Song, cause error) error {
+	queued := q.queuedAlternatives(index)
+	if (len(queued) == 0 && song.Name == "") || q.hasStarted() {
+		// nothing to try, or a failure in the middle of the song
+		return cause
+	}
+
+	tried := map[SongRef]bool{song.Ref(): true}
+	for _, find := range []func() []Song{
+		func() []Song { return queued },
+		func() []Song { return q.searchAlternatives(song) },
+	} {
+		for _, alternative := range find() {
+			if tried[alternative.Ref()] {
+				continue
+			}
+			tried[alternative.Ref()] = true
+
+			log.Warn().Msgf("unable to play song %s (%s), playing %s instead", song.Ref(), cause, alternative.Ref())
+			err := q.playSong(index, alternative, true)
+			if err == nil || q.hasStarted() {
+				return err
+			}
+			cause = err
+		}
+	}
+	return cause
+}
+
+func (q *queue) queuedAlternatives(index int) []Song {
+	q.RLock()
+	defer q.RUnlock()
+	return q.alternatives[index]
+}
+
+// searchAlternatives finds song on the other connectors, none are found when it has no name to search for
+func (q *queue) searchAlternatives(song Song) []Song {
+	if song.Name == "" {
+		return nil
+	}
+
+	alternatives, err := q.app.Alternatives(song)
+	if err != nil {
+		log.Warn().Msgf("unable to find song %s on other connectors: %s", song.Ref(), err)
+	}
+	return alternatives
+}
+
+// autoplayAfter queues the songs recommended after song, skipping the queued and the recently played ones
+func (q *queue) autoplayAfter(song Song) {
+	recommended, err := q.app.Recommend(song)
+	if err != nil {
+		log.Warn().Msgf("unable to find songs to play after %s: %s", song.Ref(), err)
+		return
+	}
+
+	var recent []HistoryEntry
+	if history := q.app.Storage().History; history != nil {
+		if recent, err = history.List(HistoryFilter{Limit: autoplayRecentHistory}); err != nil {
+			log.Warn().Msgf("unable to read the history, played songs may be repeated: %s", err)
+		}
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	seen := append([]Song(nil), q.songs...)
+	for _, e := range recent {
+		seen = append(seen, e.Song)
+	}
+
+	added := 0
+	for _, s := range recommended {
+		if added == autoplayBatch {
+			break
+		}
+		if containsSameSong(seen, s) {
+			continue
+		}
+		q.songs = append(q.songs, s)
+		seen = append(seen, s)
+		added++
+	}
+}
+
+func containsSameSong(songs []Song, song Song) bool {
+	for _, s := range songs {
+		if SameSong(s, song) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot captures the songs, the position and the volume of the queue
+func (q *queue) Snapshot() Session {
+	q.RLock()
+	defer q.RUnlock()
+
+	session := Session{
+		Songs:  append([]Song(nil), q.songs...),
+		Index:  q.index,
+		Pos:    q.pendingSeek,
+		Volume: q.volume,
+	}
+	if q.current != nil && !q.jumped {
+		if status := q.current.Report(); status.State == StatePlaying || status.State == StatePaused {
+			session.Pos = status.Pos
+		}
+	}
+	return session
+}
+
+// Restore replaces the songs of a stopped queue with the session ones,
+// playing it again resumes where the session was left
+func (q *queue) Restore(session Session) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.songs = append([]Song(nil), session.Songs...)
+	q.alternatives = nil
+	q.index = session.Index
+	q.pendingSeek = session.Pos
+	q.volume = ClampVolume(session.Volume)
+}
+
+// applyPending must be called with the lock held
+func (q *queue) applyPending(player Player) error {
+	seek, loop := q.pendingSeek, q.pendingLoop
+	q.pendingSeek, q.pendingLoop = 0, Segment{}
+
+	if q.volume != DefaultVolume {
+		player.SetVolume(q.volume)
+	}
+
+	if seek > 0 {
+		if err := player.Seek(seek); err != nil {
+			return err
+		}
+	}
+	if !loop.IsEmpty() {
+		return player.SetLoop(loop)
+	}
+	return nil
+}
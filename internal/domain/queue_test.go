@@ -0,0 +1,519 @@
+package domain
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockApp struct {
+	mock.Mock
+}
+
+func (m *mockApp) Init() error {
+	return m.Called().Error(0)
+}
+
+func (m *mockApp) ConnectorNames() []string {
+	return m.Called().Get(0).([]string)
+}
+
+func (m *mockApp) Search(cName, term string) ([]Song, error) {
+	called := m.Called(cName, term)
+	return called.Get(0).([]Song), called.Error(1)
+}
+
+func (m *mockApp) SearchAll(term string) ([]Song, error) {
+	called := m.Called(term)
+	return called.Get(0).([]Song), called.Error(1)
+}
+
+func (m *mockApp) Song(ref SongRef) (StreamableSong, error) {
+	called := m.Called(ref)
+	return called.Get(0).(StreamableSong), called.Error(1)
+}
+
+func (m *mockApp) SongDetails(ref SongRef) (Song, error) {
+	called := m.Called(ref)
+	return called.Get(0).(Song), called.Error(1)
+}
+
+func (m *mockApp) Play(ref SongRef) (Player, error) {
+	called := m.Called(ref)
+	return called.Get(0).(Player), called.Error(1)
+}
+
+func (m *mockApp) Alternatives(song Song) ([]Song, error) {
+	called := m.Called(song)
+	return called.Get(0).([]Song), called.Error(1)
+}
+
+func (m *mockApp) Recommend(song Song) ([]Song, error) {
+	called := m.Called(song)
+	return called.Get(0).([]Song), called.Error(1)
+}
+
+func (m *mockApp) Lyrics(ref SongRef) (Lyrics, error) {
+	called := m.Called(ref)
+	return called.Get(0).(Lyrics), called.Error(1)
+}
+
+func (m *mockApp) Playlist(source PlaylistSource) (Playlist, error) {
+	called := m.Called(source)
+	return called.Get(0).(Playlist), called.Error(1)
+}
+
+func (m *mockApp) Chart(connectorName string, kind ChartKind, region string) (Chart, error) {
+	called := m.Called(connectorName, kind, region)
+	return called.Get(0).(Chart), called.Error(1)
+}
+
+func (m *mockApp) Suggest(prefix string) ([]string, error) {
+	called := m.Called(prefix)
+	return called.Get(0).([]string), called.Error(1)
+}
+
+func (m *mockApp) ResolveLink(raw string) (Link, error) {
+	called := m.Called(raw)
+	return called.Get(0).(Link), called.Error(1)
+}
+
+func (m *mockApp) CheckForUpdate() (UpdateStatus, error) {
+	called := m.Called()
+	return called.Get(0).(UpdateStatus), called.Error(1)
+}
+
+func (m *mockApp) Storage() Storage {
+	return m.Called().Get(0).(Storage)
+}
+
+func (m *mockApp) Preferences() Preferences {
+	return m.Called().Get(0).(Preferences)
+}
+
+// fakePlayer plays for duration (forever if zero) unless stopped or faded out
+type fakePlayer struct {
+	sync.Mutex
+	duration time.Duration
+	err      error
+	state    State
+	fadedOut time.Duration
+	seeked   time.Duration
+	loop     Segment
+	volume   int
+	stop     chan struct{}
+}
+
+func newFakePlayer(duration time.Duration, err error) *fakePlayer {
+	return &fakePlayer{duration: duration, err: err, state: StateNotInitialized, stop: make(chan struct{})}
+}
+
+func (p *fakePlayer) Start() error {
+	p.setState(StatePlaying)
+	var timeout <-chan time.Time
+	if p.duration > 0 {
+		timeout = time.After(p.duration)
+	}
+	select {
+	case <-timeout:
+	case <-p.stop:
+	}
+	p.setState(StateStopped)
+	return p.err
+}
+
+func (p *fakePlayer) setState(s State) {
+	p.Lock()
+	defer p.Unlock()
+	p.state = s
+}
+
+func (p *fakePlayer) PauseOrResume() {}
+
+func (p *fakePlayer) Stop() {
+	p.Lock()
+	defer p.Unlock()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+}
+
+func (p *fakePlayer) FadeOut(d time.Duration) {
+	p.Lock()
+	p.fadedOut = d
+	p.Unlock()
+	p.Stop()
+}
+
+func (p *fakePlayer) Seek(pos time.Duration) error {
+	p.Lock()
+	defer p.Unlock()
+	p.seeked = pos
+	return nil
+}
+
+func (p *fakePlayer) SetLoop(loop Segment) error {
+	p.Lock()
+	defer p.Unlock()
+	p.loop = loop
+	return nil
+}
+
+func (p *fakePlayer) SetVolume(percent int) {
+	p.Lock()
+	defer p.Unlock()
+	p.volume = percent
+}
+
+func (p *fakePlayer) Report() PlayerStatus {
+	p.Lock()
+	defer p.Unlock()
+	return PlayerStatus{State: p.state, Loop: p.loop, Pos: p.seeked}
+}
+
+func TestParseSleepTimer(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SleepTimer
+		wantErr bool
+	}{
+		{"", SleepTimer{Mode: SleepOff}, false},
+		{"off", SleepTimer{Mode: SleepOff}, false},
+		{"song", SleepTimer{Mode: SleepAfterSong}, false},
+		{"30m", SleepTimer{Mode: SleepAfterTime, After: 30 * time.Minute}, false},
+		{"-1m", SleepTimer{}, true},
+		{"tomorrow", SleepTimer{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSleepTimer(tt.input)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_queue_plays_songs_in_order(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "2"}).Return(&fakePlayer{}, errors.New("unexpected")).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "3"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"}, Song{Id: "3", Connector: "c"})
+	require.NoError(t, q.Play(0))
+	require.EqualError(t, q.Wait(), "unexpected")
+
+	report := q.Report()
+	require.Equal(t, 2, report.Playing)
+	require.Equal(t, 3, report.Index)
+	require.EqualValues(t, StateStopped, report.Player.State)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_Play_out_of_bounds(t *testing.T) {
+	q := NewQueue(&mockApp{})
+	require.Error(t, q.Play(0))
+	require.NoError(t, q.Wait())
+}
+
+func Test_queue_Next(t *testing.T) {
+	first := newFakePlayer(0, nil)
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(first, nil).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "2"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"})
+	require.NoError(t, q.Play(0))
+	require.Eventually(t, func() bool { return q.Report().Player.State == StatePlaying }, time.Second, time.Millisecond)
+	q.Next()
+	require.NoError(t, q.Wait())
+	require.Equal(t, 1, q.Report().Playing)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_records_skipped_songs_only(t *testing.T) {
+	h := NewHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(newRecordingPlayer(newFakePlayer(0, nil), h), nil).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "2"}).Return(newRecordingPlayer(newFakePlayer(0, nil), h), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"})
+	require.NoError(t, q.Play(0))
+	require.Eventually(t, func() bool { return q.Report().Player.State == StatePlaying }, time.Second, time.Millisecond)
+	q.Next()
+	require.Eventually(t, func() bool { return q.Report().Playing == 1 }, time.Second, time.Millisecond)
+	q.Stop()
+	require.NoError(t, q.Wait())
+
+	entries, err := h.List(HistoryFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.True(t, entries[0].Skipped, "left for the next song")
+	require.False(t, entries[1].Skipped, "stopped")
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_Sleep_after_song(t *testing.T) {
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"})
+	q.Sleep(SleepTimer{Mode: SleepAfterSong})
+	require.NoError(t, q.Play(0))
+	require.NoError(t, q.Wait())
+
+	report := q.Report()
+	require.Equal(t, 0, report.Playing)
+	require.Equal(t, SleepOff, report.Sleep.Mode)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_Sleep_after_time(t *testing.T) {
+	player := newFakePlayer(0, nil)
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(player, nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"})
+	require.NoError(t, q.Play(0))
+	require.Eventually(t, func() bool { return q.Report().Player.State == StatePlaying }, time.Second, time.Millisecond)
+
+	q.Sleep(SleepTimer{Mode: SleepAfterTime, After: 50 * time.Millisecond})
+	require.Equal(t, SleepAfterTime, q.Report().Sleep.Mode)
+	require.NoError(t, q.Wait())
+
+	player.Lock()
+	defer player.Unlock()
+	require.Equal(t, 50*time.Millisecond, player.fadedOut)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_applies_pending_seek_and_loop(t *testing.T) {
+	first, second := newFakePlayer(0, nil), newFakePlayer(0, nil)
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(first, nil).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "2"}).Return(second, nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(Song{Id: "1", Connector: "c"}, Song{Id: "2", Connector: "c"})
+	require.NoError(t, q.Seek(time.Minute))
+	require.NoError(t, q.SetLoop(Segment{Start: time.Minute, End: 2 * time.Minute}))
+	require.NoError(t, q.Play(0))
+	require.Eventually(t, func() bool { return q.Report().Player.State == StatePlaying }, time.Second, time.Millisecond)
+
+	first.Lock()
+	require.Equal(t, time.Minute, first.seeked)
+	require.Equal(t, Segment{Start: time.Minute, End: 2 * time.Minute}, first.loop)
+	first.Unlock()
+
+	// the loop belongs to the current song only
+	q.Next()
+	require.Eventually(t, func() bool { return q.Report().Playing == 1 }, time.Second, time.Millisecond)
+	require.True(t, q.Report().Player.Loop.IsEmpty())
+	q.Stop()
+	require.NoError(t, q.Wait())
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_Snapshot_Restore(t *testing.T) {
+	player := newFakePlayer(0, nil)
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "2"}).Return(player, nil).Once()
+
+	q := NewQueue(ma)
+	q.Restore(Session{
+		Songs:  []Song{{Id: "1", Connector: "c"}, {Id: "2", Connector: "c"}},
+		Index:  1,
+		Pos:    time.Minute,
+		Volume: 40,
+	})
+	require.Equal(t, Session{
+		Songs:  []Song{{Id: "1", Connector: "c"}, {Id: "2", Connector: "c"}},
+		Index:  1,
+		Pos:    time.Minute,
+		Volume: 40,
+	}, q.Snapshot())
+
+	require.NoError(t, q.Play(1))
+	require.Eventually(t, func() bool { return q.Report().Player.State == StatePlaying }, time.Second, time.Millisecond)
+	q.SetVolume(120)
+
+	player.Lock()
+	require.Equal(t, time.Minute, player.seeked)
+	require.Equal(t, MaxVolume, player.volume)
+	player.Unlock()
+	require.Equal(t, time.Minute, q.Snapshot().Pos)
+	require.Equal(t, MaxVolume, q.Snapshot().Volume)
+
+	q.Stop()
+	require.NoError(t, q.Wait())
+	ma.AssertExpectations(t)
+}
+
+// countingStreamer streams its position as samples
+type countingStreamer struct {
+	pos, len int
+}
+
+func (s *countingStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) && s.pos < s.len {
+		samples[n] = [2]float64{float64(s.pos), float64(s.pos)}
+		s.pos++
+		n++
+	}
+	return n, n > 0
+}
+
+func (s *countingStreamer) Err() error {
+	return nil
+}
+
+func (s *countingStreamer) Len() int {
+	return s.len
+}
+
+func (s *countingStreamer) Position() int {
+	return s.pos
+}
+
+func (s *countingStreamer) Seek(p int) error {
+	s.pos = p
+	return nil
+}
+
+func Test_looper_Stream(t *testing.T) {
+	l := &looper{StreamSeeker: &countingStreamer{len: 10}, start: 2, end: 5}
+
+	samples := make([][2]float64, 8)
+	n, ok := l.Stream(samples)
+	require.True(t, ok)
+	require.Equal(t, 8, n)
+	var got []float64
+	for _, s := range samples {
+		got = append(got, s[0])
+	}
+	require.Equal(t, []float64{0, 1, 2, 3, 4, 2, 3, 4}, got)
+
+	// disabling the loop streams until the end
+	l.start, l.end = 0, 0
+	n, ok = l.Stream(samples)
+	require.True(t, ok)
+	require.Equal(t, 5, n)
+	n, ok = l.Stream(samples)
+	require.False(t, ok)
+	require.Equal(t, 0, n)
+}
+
+func Test_fader_Stream(t *testing.T) {
+	f := &fader{Streamer: beep.StreamerFunc(func(samples [][2]float64) (int, bool) {
+		for i := range samples {
+			samples[i] = [2]float64{1, 1}
+		}
+		return len(samples), true
+	}), left: -1}
+
+	samples := make([][2]float64, 4)
+	n, ok := f.Stream(samples)
+	require.True(t, ok)
+	require.Equal(t, 4, n)
+	require.Equal(t, [2]float64{1, 1}, samples[3])
+
+	f.total, f.left = 4, 4
+	n, ok = f.Stream(samples)
+	require.True(t, ok)
+	require.Equal(t, 4, n)
+	require.Equal(t, [][2]float64{{1, 1}, {0.75, 0.75}, {0.5, 0.5}, {0.25, 0.25}}, samples)
+
+	n, ok = f.Stream(samples)
+	require.False(t, ok)
+	require.Equal(t, 0, n)
+}
+
+func Test_queue_plays_an_alternative_when_a_song_fails(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(&fakePlayer{}, errors.New("error getting song id=1")).Once()
+	ma.On("Alternatives", song).Return([]Song{{Id: "a", Connector: "d"}, {Id: "b", Connector: "e"}}, nil).Once()
+	ma.On("Play", SongRef{Connector: "d", Id: "a"}).Return(newFakePlayer(time.Millisecond, errors.New("got unexpected status code 403")), nil).Once()
+	ma.On("Play", SongRef{Connector: "e", Id: "b"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(song)
+	require.NoError(t, q.Play(0))
+	require.NoError(t, q.Wait())
+
+	report := q.Report()
+	require.Equal(t, 0, report.Playing)
+	require.True(t, report.Alternative)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_plays_the_queued_alternatives_first(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	queued := Song{Id: "a", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "d"}
+	found := Song{Id: "b", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "e"}
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(&fakePlayer{}, errors.New("vip only")).Once()
+	ma.On("Play", SongRef{Connector: "d", Id: "a"}).Return(&fakePlayer{}, errors.New("vip only")).Once()
+	ma.On("Alternatives", song).Return([]Song{queued, found}, nil).Once()
+	ma.On("Play", SongRef{Connector: "e", Id: "b"}).Return(newFakePlayer(10*time.Millisecond, nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.AddGroups(SongGroup{Song: song, Alternatives: []Song{queued}})
+	require.NoError(t, q.Play(0))
+	require.NoError(t, q.Wait())
+
+	require.True(t, q.Report().Alternative)
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_returns_the_error_when_no_alternative_plays(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Connector: "c"}
+	ma := &mockApp{}
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(&fakePlayer{}, errors.New("vip only")).Once()
+	ma.On("Alternatives", song).Return([]Song(nil), nil).Once()
+
+	q := NewQueue(ma)
+	q.Add(song)
+	require.NoError(t, q.Play(0))
+	require.EqualError(t, q.Wait(), "vip only")
+	ma.AssertExpectations(t)
+}
+
+func Test_queue_autoplay_queues_recommended_songs(t *testing.T) {
+	song := Song{Id: "1", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	played := Song{Id: "2", Name: "Nơi này có anh", Artists: "Sơn Tùng M-TP", Connector: "c"}
+	next := Song{Id: "3", Name: "Chúng ta không thuộc về nhau", Artists: "Sơn Tùng M-TP", Connector: "c"}
+
+	storage := NewStorage(t.TempDir())
+	require.NoError(t, storage.History.Record(HistoryEntry{Song: played, StartedAt: time.Now()}))
+
+	ma := &mockApp{}
+	ma.On("Storage").Return(storage)
+	ma.On("Play", SongRef{Connector: "c", Id: "1"}).Return(newFakePlayer(time.Millisecond, nil), nil).Once()
+	ma.On("Recommend", song).Return([]Song{song, played, next}, nil).Once()
+	ma.On("Play", SongRef{Connector: "c", Id: "3"}).Return(newFakePlayer(time.Millisecond, nil), nil).Once()
+	ma.On("Recommend", next).Return([]Song(nil), errors.New("no recommendation")).Once()
+
+	q := NewQueue(ma)
+	q.SetAutoplay(true)
+	q.Add(song)
+	require.NoError(t, q.Play(0))
+	require.NoError(t, q.Wait())
+
+	require.Equal(t, []Song{song, next}, q.Songs())
+	require.True(t, q.Report().Autoplay)
+	ma.AssertExpectations(t)
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"io.github.binatory/budich-cli/internal/vietnamese"
+)
+
+const (
+	// the durations of two uploads of the same song differ by a few seconds at most, a remix or a live is longer
+	sameSongDurationTolerance = 5 * time.Second
+	sameSongNameSimilarity    = 0.8
+	sameSongArtistsSimilarity = 0.5
+)
+
+// SongGroup is a song along with the same song found on other connectors or uploaded several times
+type SongGroup struct {
+	Song
+	Alternatives []Song
+}
+
+// Sources returns the song then its alternatives, in the order to try them
+func (g SongGroup) Sources() []Song {
+	return append([]Song{g.Song}, g.Alternatives...)
+}
+
+// RankSongs sorts songs by relevance to query, then groups the duplicates under their most relevant one.
+// The order of songs is kept among equals, which is the order of the connectors for merged results
+func RankSongs(query string, songs []Song) []SongGroup {
+	variants := vietnamese.Variants(query)
+	scores := make([]float64, len(songs))
+	order := make([]int, len(songs))
+	for idx, s := range songs {
+		scores[idx] = relevance(variants, s)
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	var groups []SongGroup
+	for _, idx := range order {
+		song := songs[idx]
+		grouped := false
+		for g := range groups {
+			if SameSong(groups[g].Song, song) {
+				groups[g].Alternatives = append(groups[g].Alternatives, song)
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			groups = append(groups, SongGroup{Song: song})
+		}
+	}
+	return groups
+}
+
+// SameSong tells whether a and b are likely the same recording, judging by their names, artists and durations
+func SameSong(a, b Song) bool {
+	if a.Ref() == b.Ref() {
+		return true
+	}
+	if similarity(words(a.Name), words(b.Name)) < sameSongNameSimilarity {
+		return false
+	}
+	if a.Artists != "" && b.Artists != "" && similarity(words(a.Artists), words(b.Artists)) < sameSongArtistsSimilarity {
+		return false
+	}
+	if a.Duration > 0 && b.Duration > 0 {
+		diff := a.Duration - b.Duration
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > sameSongDurationTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// relevance is the best share of the words of a query variant found in the song,
+// plus a bonus for the songs whose name is the query itself rather than a longer title containing it
+func relevance(variants []string, s Song) float64 {
+	text := vietnamese.Normalize(s.Name + " " + s.Artists)
+	name := words(s.Name)
+
+	best := 0.0
+	for _, variant := range variants {
+		queryWords := strings.Fields(variant)
+		found := 0
+		for _, w := range queryWords {
+			if strings.Contains(text, w) {
+				found++
+			}
+		}
+		score := float64(found)/float64(len(queryWords)) + similarity(queryWords, name)/2
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func words(s string) []string {
+	return strings.Fields(vietnamese.Normalize(s))
+}
+
+// similarity is the Dice coefficient of two sets of words, from 0 when they share none to 1 when they are the same
+func similarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	common := 0
+	seen := make(map[string]bool, len(b))
+	for _, w := range b {
+		if set[w] && !seen[w] {
+			common++
+		}
+		seen[w] = true
+	}
+	return 2 * float64(common) / float64(len(set)+len(seen))
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankSongs(t *testing.T) {
+	chayNgayDi := Song{Id: "1", Name: "Chạy ngay đi", Artists: "Sơn Tùng M-TP", Duration: 248 * time.Second, Connector: "zmp3"}
+	lacTroi := Song{Id: "2", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233 * time.Second, Connector: "zmp3"}
+	lacTroiNct := Song{Id: "a", Name: "Lạc Trôi", Artists: "Sơn Tùng MTP", Duration: 235 * time.Second, Connector: "nct"}
+	lacTroiRemix := Song{Id: "b", Name: "Lạc Trôi (Remix)", Artists: "Sơn Tùng M-TP", Duration: 280 * time.Second, Connector: "nct"}
+	songs := []Song{chayNgayDi, lacTroiRemix, lacTroi, lacTroiNct}
+
+	tests := []struct {
+		query string
+		want  []SongGroup
+	}{
+		{"lạc trôi", []SongGroup{
+			{Song: lacTroi, Alternatives: []Song{lacTroiNct}},
+			{Song: lacTroiRemix},
+			{Song: chayNgayDi},
+		}},
+		{"lacj trooi", []SongGroup{
+			{Song: lacTroi, Alternatives: []Song{lacTroiNct}},
+			{Song: lacTroiRemix},
+			{Song: chayNgayDi},
+		}},
+		{"chay ngay di", []SongGroup{
+			{Song: chayNgayDi},
+			{Song: lacTroiRemix},
+			{Song: lacTroi, Alternatives: []Song{lacTroiNct}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.want, RankSongs(tt.query, songs))
+		})
+	}
+}
+
+func TestSameSong(t *testing.T) {
+	song := Song{Id: "1", Name: "Nơi này có anh", Artists: "Sơn Tùng M-TP", Duration: 260 * time.Second, Connector: "zmp3"}
+	tests := []struct {
+		name  string
+		other Song
+		want  bool
+	}{
+		{"same ref", Song{Id: "1", Connector: "zmp3"}, true},
+		{"other connector", Song{Id: "a", Name: "Noi Nay Co Anh", Artists: "Sơn Tùng M-TP", Duration: 262 * time.Second, Connector: "nct"}, true},
+		{"unknown duration and artists", Song{Id: "a", Name: "Nơi này có anh", Connector: "nct"}, true},
+		{"other name", Song{Id: "a", Name: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 260 * time.Second, Connector: "nct"}, false},
+		{"other artists", Song{Id: "a", Name: "Nơi này có anh", Artists: "Adele", Duration: 260 * time.Second, Connector: "nct"}, false},
+		{"other duration", Song{Id: "a", Name: "Nơi này có anh", Artists: "Sơn Tùng M-TP", Duration: 300 * time.Second, Connector: "nct"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, SameSong(song, tt.other))
+			require.Equal(t, tt.want, SameSong(tt.other, song))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/vietnamese"
+)
+
+// Recommender is implemented by the connectors able to find songs related to one of theirs
+type Recommender interface {
+	Recommend(song Song) ([]Song, error)
+}
+
+// mainArtist returns the first artist of song, the one used to find more of its songs
+func mainArtist(song Song) string {
+	if len(song.ArtistList) > 0 {
+		return song.ArtistList[0].Name
+	}
+	return strings.TrimSpace(strings.Split(song.Artists, ",")[0])
+}
+
+// moreByArtist searches for the other songs of the main artist of song
+func (a *app) moreByArtist(song Song) ([]Song, error) {
+	artist := mainArtist(song)
+	if artist == "" {
+		return nil, errors.Errorf("song %s has no artist to search for", song.Ref())
+	}
+
+	var found []Song
+	var err error
+	if _, ok := a.connectors[song.Connector]; ok {
+		found, err = a.search(song.Connector, artist)
+	} else {
+		found, err = a.searchAll(artist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var songs []Song
+	for _, s := range found {
+		if vietnamese.Match(s.Artists, artist) && !SameSong(s, song) {
+			songs = append(songs, s)
+		}
+	}
+	return songs, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/utils"
+)
+
+// Session is the state of a queue which can be restored later on
+type Session struct {
+	Songs  []Song        `json:"songs"`
+	Index  int           `json:"index"`
+	Pos    time.Duration `json:"pos"`
+	Volume int           `json:"volume"`
+}
+
+// IsResumable tells whether there is still a song left to play
+func (s Session) IsResumable() bool {
+	return s.Index >= 0 && s.Index < len(s.Songs)
+}
+
+type SessionStore interface {
+	Load() (Session, error)
+	Save(session Session) error
+}
+
+type sessionStore struct {
+	sync.Mutex
+	path string
+}
+
+func NewSessionStore(path string) SessionStore {
+	return &sessionStore{path: path}
+}
+
+// Load returns the last saved session, or an empty one if none has been saved yet
+func (s *sessionStore) Load() (Session, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	session := Session{Volume: DefaultVolume}
+	if err := utils.ReadJSON(s.path, &session); err != nil {
+		return Session{}, errors.Wrap(err, "error loading session")
+	}
+	return session, nil
+}
+
+func (s *sessionStore) Save(session Session) error {
+	s.Lock()
+	defer s.Unlock()
+
+	return errors.Wrap(utils.WriteJSON(s.path, session), "error saving session")
+}
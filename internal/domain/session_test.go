@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sessionStore(t *testing.T) {
+	s := NewSessionStore(filepath.Join(t.TempDir(), "session.json"))
+
+	session, err := s.Load()
+	require.NoError(t, err)
+	require.Equal(t, Session{Volume: DefaultVolume}, session)
+	require.False(t, session.IsResumable())
+
+	saved := Session{Songs: []Song{{Id: "1", Name: "Song", Connector: "c"}}, Index: 0, Pos: time.Minute, Volume: 30}
+	require.NoError(t, s.Save(saved))
+	session, err = s.Load()
+	require.NoError(t, err)
+	require.Equal(t, saved, session)
+	require.True(t, session.IsResumable())
+}
@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/wav"
+	"github.com/pkg/errors"
+)
+
+const (
+	SinkSpeaker = "speaker"
+	SinkNull    = "null"
+	SinkWav     = "wav"
+)
+
+const (
+	defaultSampleRate = beep.SampleRate(48000)
+	sinkBufferPeriod  = time.Second / 10
+)
+
+// Sink is the audio output where players send their decoded samples to
+type Sink interface {
+	SampleRate() beep.SampleRate
+	Play(s beep.Streamer) error
+	Lock()
+	Unlock()
+	Close() error
+}
+
+// SpeakerFactory creates the sink playing through the sound device. It is provided by the caller
+// since the sound device requires the audio libraries of the system, which domain does without
+type SpeakerFactory func(sampleRate beep.SampleRate) Sink
+
+// NewSink creates a sink from its spec: "speaker", "null" or "wav:<path>".
+// Nothing is opened until the first call to Play.
+func NewSink(spec string, sampleRate beep.SampleRate, speaker SpeakerFactory) (Sink, error) {
+	kind, path, err := parseSinkSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case SinkNull:
+		return NewNullSink(sampleRate), nil
+	case SinkWav:
+		return NewWavSink(sampleRate, path), nil
+	default:
+		return speaker(sampleRate), nil
+	}
+}
+
+// parseSinkSpec splits spec into the kind of the sink and the path of the file it writes to
+func parseSinkSpec(spec string) (string, string, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	switch parts[0] {
+	case "", SinkSpeaker:
+		return SinkSpeaker, "", nil
+	case SinkNull:
+		return SinkNull, "", nil
+	case SinkWav:
+		if len(parts) != 2 || parts[1] == "" {
+			return "", "", errors.Errorf("missing output path in sink %s, expected wav:<path>", spec)
+		}
+		return SinkWav, parts[1], nil
+	default:
+		return "", "", errors.Errorf("sink %s not recognized", spec)
+	}
+}
+
+// pauser is implemented by the streamers which can be paused, the soft sinks do not write their silence
+type pauser interface {
+	Paused() bool
+}
+
+// softSink mixes streamers in software and paces them with the wall clock,
+// as a sound device would do, then hands the mixed samples to consume.
+// Nothing is handed while no streamer is playing, so the idle and paused times are not recorded
+type softSink struct {
+	sync.Mutex
+	sampleRate beep.SampleRate
+	streamers  []beep.Streamer
+	buf        [][2]float64
+	open       func() (func(beep.Streamer) error, error)
+
+	startOnce sync.Once
+	startErr  error
+	done      chan struct{}
+	finished  chan error
+}
+
+// NewNullSink creates a sink discarding everything it plays
+func NewNullSink(sampleRate beep.SampleRate) Sink {
+	return newSoftSink(sampleRate, func() (func(beep.Streamer) error, error) {
+		return func(s beep.Streamer) error {
+			samples := make([][2]float64, sampleRate.N(sinkBufferPeriod))
+			for {
+				if _, ok := s.Stream(samples); !ok {
+					return nil
+				}
+			}
+		}, nil
+	})
+}
+
+// NewWavSink creates a sink recording everything it plays into a WAV file at path
+func NewWavSink(sampleRate beep.SampleRate, path string) Sink {
+	return newSoftSink(sampleRate, func() (func(beep.Streamer) error, error) {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating wav file %s", path)
+		}
+
+		format := beep.Format{SampleRate: sampleRate, NumChannels: 2, Precision: 2}
+		return func(s beep.Streamer) error {
+			defer f.Close()
+			return errors.Wrapf(wav.Encode(f, s, format), "error writing wav file %s", path)
+		}, nil
+	})
+}
+
+func newSoftSink(sampleRate beep.SampleRate, open func() (func(beep.Streamer) error, error)) *softSink {
+	return &softSink{
+		sampleRate: sampleRate,
+		open:       open,
+		done:       make(chan struct{}),
+		finished:   make(chan error, 1),
+	}
+}
+
+func (s *softSink) start() error {
+	s.startOnce.Do(func() {
+		consume, err := s.open()
+		if err != nil {
+			s.startErr = err
+			return
+		}
+
+		go func() {
+			s.finished <- consume(&pacedStreamer{sink: s, start: time.Now()})
+		}()
+	})
+	return s.startErr
+}
+
+func (s *softSink) SampleRate() beep.SampleRate {
+	return s.sampleRate
+}
+
+func (s *softSink) Play(streamer beep.Streamer) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.streamers = append(s.streamers, streamer)
+	s.Unlock()
+	return nil
+}
+
+// playing tells whether a streamer is playing rather than paused, it must be called with the sink locked
+func (s *softSink) playing() bool {
+	for _, streamer := range s.streamers {
+		if p, ok := streamer.(pauser); !ok || !p.Paused() {
+			return true
+		}
+	}
+	return false
+}
+
+// mix sums up the samples of the streamers into samples then drops the drained streamers,
+// it must be called with the sink locked
+func (s *softSink) mix(samples [][2]float64) {
+	if len(s.buf) < len(samples) {
+		s.buf = make([][2]float64, len(samples))
+	}
+	for i := range samples {
+		samples[i] = [2]float64{}
+	}
+
+	left := s.streamers[:0]
+	for _, streamer := range s.streamers {
+		n, ok := streamer.Stream(s.buf[:len(samples)])
+		for i := range s.buf[:n] {
+			samples[i][0] += s.buf[i][0]
+			samples[i][1] += s.buf[i][1]
+		}
+		if ok {
+			left = append(left, streamer)
+		}
+	}
+	s.streamers = left
+}
+
+func (s *softSink) Close() error {
+	s.startOnce.Do(func() {
+		s.startErr = errors.New("sink closed")
+	})
+
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+
+	if s.startErr != nil {
+		return nil
+	}
+	return <-s.finished
+}
+
+// pacedStreamer streams the mix of a softSink no faster than real time, it waits
+// while nothing is playing and drains once the sink is closed
+type pacedStreamer struct {
+	sink     *softSink
+	start    time.Time
+	streamed int
+}
+
+func (ps *pacedStreamer) Stream(samples [][2]float64) (int, bool) {
+	for {
+		wait := time.Until(ps.start.Add(ps.sink.sampleRate.D(ps.streamed)))
+		select {
+		case <-ps.sink.done:
+			return 0, false
+		case <-time.After(wait):
+		}
+
+		ps.sink.Lock()
+		playing := ps.sink.playing()
+		if playing {
+			ps.sink.mix(samples)
+		}
+		ps.sink.Unlock()
+
+		if playing {
+			ps.streamed += len(samples)
+			return len(samples), true
+		}
+		// look again a bit later, the pace starts over from there
+		ps.start, ps.streamed = time.Now().Add(sinkBufferPeriod), 0
+	}
+}
+
+func (ps *pacedStreamer) Err() error {
+	return nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/wav"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSpeaker struct {
+	Sink
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"default", "", fakeSpeaker{}, false},
+		{"speaker", "speaker", fakeSpeaker{}, false},
+		{"null", "null", &softSink{}, false},
+		{"wav", "wav:/tmp/out.wav", &softSink{}, false},
+		{"wav without path", "wav", nil, true},
+		{"unknown", "toto", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSink(tt.spec, defaultSampleRate, func(sampleRate beep.SampleRate) Sink {
+				return fakeSpeaker{NewNullSink(sampleRate)}
+			})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.IsType(t, tt.want, got)
+			require.Equal(t, defaultSampleRate, got.SampleRate())
+		})
+	}
+}
+
+func playSilence(t *testing.T, s Sink, d time.Duration) {
+	done := make(chan struct{})
+	silence := beep.Silence(s.SampleRate().N(d))
+	require.NoError(t, s.Play(beep.Seq(silence, beep.Callback(func() {
+		close(done)
+	}))))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("streamer has not been drained by the sink")
+	}
+}
+
+func Test_nullSink_Play(t *testing.T) {
+	s := NewNullSink(beep.SampleRate(8000))
+	start := time.Now()
+	playSilence(t, s, 200*time.Millisecond)
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond), "sink must be paced with the wall clock")
+	require.NoError(t, s.Close())
+}
+
+func Test_wavSink_Play(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+
+	s := NewWavSink(beep.SampleRate(8000), path)
+	playSilence(t, s, 200*time.Millisecond)
+	require.NoError(t, s.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	streamer, format, err := wav.Decode(f)
+	require.NoError(t, err)
+	require.Equal(t, beep.SampleRate(8000), format.SampleRate)
+	require.GreaterOrEqual(t, streamer.Len(), 1600)
+}
+
+func Test_wavSink_records_the_playing_time_only(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+
+	s := NewWavSink(beep.SampleRate(8000), path)
+	playSilence(t, s, 200*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+
+	ctrl := &beep.Ctrl{Streamer: beep.Silence(-1), Paused: true}
+	require.NoError(t, s.Play(pausable{Streamer: ctrl, ctrl: ctrl}))
+	time.Sleep(300 * time.Millisecond)
+	require.NoError(t, s.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	streamer, _, err := wav.Decode(f)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, streamer.Len(), 1600)
+	require.Less(t, streamer.Len(), 3200, "neither the idle nor the paused time must be recorded")
+}
+
+func Test_wavSink_Close_without_playing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+
+	s := NewWavSink(beep.SampleRate(8000), path)
+	require.NoError(t, s.Close())
+	require.NoFileExists(t, path)
+	require.Error(t, s.Play(beep.Silence(1)))
+}
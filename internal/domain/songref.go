@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SongRef identifies a song of a connector, it is formatted as <connector>:<id> e.g. zmp3:ZWAFE8BC
+type SongRef struct {
+	Connector string
+	Id        string
+}
+
+// ParseSongRef parses <connector>:<id>, the legacy <connector>.<id> form is accepted for zmp3 and nct
+func ParseSongRef(s string) (SongRef, error) {
+	connector, id, err := parseRef(s)
+	if err != nil {
+		return SongRef{}, errors.Errorf("invalid song %s, expected <connector>:<id>", s)
+	}
+	return SongRef{Connector: connector, Id: id}, nil
+}
+
+func (r SongRef) String() string {
+	return r.Connector + ":" + r.Id
+}
+
+// Song returns a song holding the ref only, its metadata remains to be fetched
+func (r SongRef) Song() Song {
+	return Song{Id: r.Id, Connector: r.Connector}
+}
+
+// MarshalText writes the ref as formatted by String, the zero ref is written empty
+func (r SongRef) MarshalText() ([]byte, error) {
+	if r == (SongRef{}) {
+		return []byte{}, nil
+	}
+	return []byte(r.String()), nil
+}
+
+func (r *SongRef) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = SongRef{}
+		return nil
+	}
+	ref, err := ParseSongRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// legacyConnectors are the connectors whose songs were written <connector>.<id> before the refs
+var legacyConnectors = map[string]bool{"zmp3": true, "nct": true}
+
+// parseRef splits <connector>:<id> on the first separator, or <connector>.<id> for the legacy connectors
+// so that file names such as song.mp3 are not taken for refs
+func parseRef(s string) (string, string, error) {
+	i := strings.IndexAny(s, ":.")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", errors.Errorf("invalid ref %s", s)
+	}
+	if s[i] == '.' && !legacyConnectors[s[:i]] {
+		return "", "", errors.Errorf("invalid ref %s", s)
+	}
+
+	connector, id := s[:i], s[i+1:]
+	if strings.ContainsAny(connector, `/\ `) || strings.HasPrefix(id, "//") {
+		// a path or an url rather than a ref
+		return "", "", errors.Errorf("invalid ref %s", s)
+	}
+	return connector, id, nil
+}
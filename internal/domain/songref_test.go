@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSongRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SongRef
+		wantErr bool
+	}{
+		{"zmp3:ZWAFE8BC", SongRef{Connector: "zmp3", Id: "ZWAFE8BC"}, false},
+		{"zmp3.ZWAFE8BC", SongRef{Connector: "zmp3", Id: "ZWAFE8BC"}, false},
+		{"nct:Fh.UQ", SongRef{Connector: "nct", Id: "Fh.UQ"}, false},
+		{"url:/music/song.mp3", SongRef{Connector: "url", Id: "/music/song.mp3"}, false},
+		{"nct.Fh.UQ", SongRef{Connector: "nct", Id: "Fh.UQ"}, false},
+		{"url.https://example.com/song.mp3", SongRef{}, true},
+		{"song.mp3", SongRef{}, true},
+		{"foo.bar", SongRef{}, true},
+		{"https://zingmp3.vn/bai-hat/a/ZWAFE8BC.html", SongRef{}, true},
+		{"/music/song.mp3", SongRef{}, true},
+		{"ZWAFE8BC", SongRef{}, true},
+		{"zmp3:", SongRef{}, true},
+		{":ZWAFE8BC", SongRef{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSongRef(tt.input)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSongRef_String(t *testing.T) {
+	ref := Song{Id: "ZWAFE8BC", Name: "Lạc trôi", Connector: "zmp3"}.Ref()
+	require.Equal(t, "zmp3:ZWAFE8BC", ref.String())
+	require.Equal(t, Song{Id: "ZWAFE8BC", Connector: "zmp3"}, ref.Song())
+}
+
+func TestSongRef_JSON_map_key(t *testing.T) {
+	var m map[SongRef]int
+	require.NoError(t, json.Unmarshal([]byte(`{"zmp3.1":1,"nct:2":2}`), &m))
+	require.Equal(t, map[SongRef]int{{Connector: "zmp3", Id: "1"}: 1, {Connector: "nct", Id: "2"}: 2}, m)
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"zmp3:1":1,"nct:2":2}`, string(data))
+}
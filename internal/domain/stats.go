@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatsItem counts the plays of a song, an artist or a connector
+type StatsItem struct {
+	Id       string        `json:"id,omitempty"`
+	Name     string        `json:"name"`
+	Plays    int           `json:"plays"`
+	Listened time.Duration `json:"listened"`
+}
+
+type Stats struct {
+	Plays         int           `json:"plays"`
+	Listened      time.Duration `json:"listened"`
+	TopSongs      []StatsItem   `json:"topSongs"`
+	TopArtists    []StatsItem   `json:"topArtists"`
+	TopConnectors []StatsItem   `json:"topConnectors"`
+}
+
+// ComputeStats ranks the songs, artists and connectors of entries by number of plays,
+// keeping the top ones only unless top is not positive
+func ComputeStats(entries []HistoryEntry, top int) Stats {
+	songs, artists, connectors := newStatsCounter(), newStatsCounter(), newStatsCounter()
+	stats := Stats{Plays: len(entries)}
+
+	for _, e := range entries {
+		stats.Listened += e.Listened
+		ref := e.Song.Ref().String()
+		songs.add(ref, StatsItem{Id: ref, Name: e.Song.Name}, e)
+		connectors.add(e.Song.Connector, StatsItem{Name: e.Song.Connector}, e)
+		for _, artist := range splitArtists(e.Song.Artists) {
+			artists.add(strings.ToLower(artist), StatsItem{Name: artist}, e)
+		}
+	}
+
+	stats.TopSongs = songs.top(top)
+	stats.TopArtists = artists.top(top)
+	stats.TopConnectors = connectors.top(top)
+	return stats
+}
+
+// statsCounter sums up the plays of the items sharing the same key
+type statsCounter struct {
+	items map[string]*StatsItem
+}
+
+func newStatsCounter() *statsCounter {
+	return &statsCounter{items: make(map[string]*StatsItem)}
+}
+
+// add counts e as a play of the item under key, first is used when the item is new
+func (sc *statsCounter) add(key string, first StatsItem, e HistoryEntry) {
+	if key == "" {
+		return
+	}
+
+	item, found := sc.items[key]
+	if !found {
+		item = &first
+		sc.items[key] = item
+	}
+	item.Plays++
+	item.Listened += e.Listened
+}
+
+func (sc *statsCounter) top(n int) []StatsItem {
+	items := make([]StatsItem, 0, len(sc.items))
+	for _, item := range sc.items {
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Plays != items[j].Plays {
+			return items[i].Plays > items[j].Plays
+		}
+		if items[i].Listened != items[j].Listened {
+			return items[i].Listened > items[j].Listened
+		}
+		return items[i].Name < items[j].Name
+	})
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
+
+// splitArtists splits the artists of a song, e.g. "Artist1, Artist2"
+func splitArtists(artists string) []string {
+	var res []string
+	for _, artist := range strings.Split(artists, ",") {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			res = append(res, artist)
+		}
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	hello := Song{Id: "1", Name: "Hello", Artists: "Adele", Connector: "zing"}
+	duet := Song{Id: "2", Name: "Duet", Artists: "Adele, Sơn Tùng M-TP", Connector: "nct"}
+	entries := []HistoryEntry{
+		{Song: hello, Listened: time.Minute},
+		{Song: duet, Listened: 2 * time.Minute},
+		{Song: hello, Listened: 3 * time.Minute},
+	}
+
+	tests := []struct {
+		name    string
+		entries []HistoryEntry
+		top     int
+		want    Stats
+	}{
+		{"all", entries, 0, Stats{
+			Plays:    3,
+			Listened: 6 * time.Minute,
+			TopSongs: []StatsItem{
+				{Id: "zing:1", Name: "Hello", Plays: 2, Listened: 4 * time.Minute},
+				{Id: "nct:2", Name: "Duet", Plays: 1, Listened: 2 * time.Minute},
+			},
+			TopArtists: []StatsItem{
+				{Name: "Adele", Plays: 3, Listened: 6 * time.Minute},
+				{Name: "Sơn Tùng M-TP", Plays: 1, Listened: 2 * time.Minute},
+			},
+			TopConnectors: []StatsItem{
+				{Name: "zing", Plays: 2, Listened: 4 * time.Minute},
+				{Name: "nct", Plays: 1, Listened: 2 * time.Minute},
+			},
+		}},
+		{"top 1", entries, 1, Stats{
+			Plays:         3,
+			Listened:      6 * time.Minute,
+			TopSongs:      []StatsItem{{Id: "zing:1", Name: "Hello", Plays: 2, Listened: 4 * time.Minute}},
+			TopArtists:    []StatsItem{{Name: "Adele", Plays: 3, Listened: 6 * time.Minute}},
+			TopConnectors: []StatsItem{{Name: "zing", Plays: 2, Listened: 4 * time.Minute}},
+		}},
+		{"empty", nil, 1, Stats{TopSongs: []StatsItem{}, TopArtists: []StatsItem{}, TopConnectors: []StatsItem{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ComputeStats(tt.entries, tt.top))
+		})
+	}
+}
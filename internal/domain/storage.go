@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// Storage groups the stores persisting the user data across sessions
+type Storage struct {
+	Bookmarks BookmarkStore
+	Session   SessionStore
+	Library   Library
+	History   History
+	Searches  SearchHistory
+}
+
+// NewStorage creates the stores keeping their files under dir
+func NewStorage(dir string) Storage {
+	return Storage{
+		Bookmarks: NewBookmarkStore(filepath.Join(dir, "bookmarks.json")),
+		Session:   NewSessionStore(filepath.Join(dir, "session.json")),
+		Library:   NewLibrary(filepath.Join(dir, "library.json")),
+		History:   NewHistory(filepath.Join(dir, "history.jsonl")),
+		Searches:  NewSearchHistory(filepath.Join(dir, "searches.json")),
+	}
+}
+
+// storedSong is a song as saved by the library and the history, identified by its ref
+// rather than by separate id and connector fields
+type storedSong struct {
+	Ref        SongRef       `json:"ref"`
+	Name       string        `json:"name"`
+	Artists    string        `json:"artists"`
+	Duration   time.Duration `json:"duration"`
+	ArtistList []Artist      `json:"artistList,omitempty"`
+	Album      *Album        `json:"album,omitempty"`
+	Year       int           `json:"year,omitempty"`
+	Genres     []string      `json:"genres,omitempty"`
+	Thumbnail  string        `json:"thumbnail,omitempty"`
+	Explicit   bool          `json:"explicit,omitempty"`
+}
+
+func newStoredSong(s Song) storedSong {
+	return storedSong{
+		Ref:        s.Ref(),
+		Name:       s.Name,
+		Artists:    s.Artists,
+		Duration:   s.Duration,
+		ArtistList: s.ArtistList,
+		Album:      s.Album,
+		Year:       s.Year,
+		Genres:     s.Genres,
+		Thumbnail:  s.Thumbnail,
+		Explicit:   s.Explicit,
+	}
+}
+
+func (s storedSong) song() Song {
+	song := s.Ref.Song()
+	song.Name, song.Artists, song.Duration = s.Name, s.Artists, s.Duration
+	song.ArtistList, song.Album, song.Year = s.ArtistList, s.Album, s.Year
+	song.Genres, song.Thumbnail, song.Explicit = s.Genres, s.Thumbnail, s.Explicit
+	return song
+}
+
+func newStoredSongs(songs []Song) []storedSong {
+	if songs == nil {
+		return nil
+	}
+	stored := make([]storedSong, len(songs))
+	for idx, s := range songs {
+		stored[idx] = newStoredSong(s)
+	}
+	return stored
+}
+
+func songsOf(stored []storedSong) []Song {
+	if stored == nil {
+		return nil
+	}
+	songs := make([]Song, len(stored))
+	for idx, s := range stored {
+		songs[idx] = s.song()
+	}
+	return songs
+}
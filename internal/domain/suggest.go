@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/utils"
+	"io.github.binatory/budich-cli/internal/vietnamese"
+)
+
+const (
+	suggestionsLimit = 10
+	// number of past searches kept to complete the next ones
+	searchHistoryLimit = 100
+)
+
+// Suggester is implemented by the connectors completing the search terms being typed
+type Suggester interface {
+	Suggest(prefix string) ([]string, error)
+}
+
+type SearchHistory interface {
+	Record(term string) error
+	// Terms returns the terms searched for, the most recent first
+	Terms() ([]string, error)
+}
+
+type searchHistory struct {
+	sync.Mutex
+	path string
+}
+
+func NewSearchHistory(path string) SearchHistory {
+	return &searchHistory{path: path}
+}
+
+func (h *searchHistory) load() ([]string, error) {
+	var terms []string
+	if err := utils.ReadJSON(h.path, &terms); err != nil {
+		return nil, errors.Wrap(err, "error loading search history")
+	}
+	return terms, nil
+}
+
+// Record moves term first, searching again for a term does not duplicate it
+func (h *searchHistory) Record(term string) error {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	terms, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	list := []string{term}
+	for _, t := range terms {
+		if !strings.EqualFold(t, term) && len(list) < searchHistoryLimit {
+			list = append(list, t)
+		}
+	}
+	return errors.Wrap(utils.WriteJSON(h.path, list), "error saving search history")
+}
+
+func (h *searchHistory) Terms() ([]string, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.load()
+}
+
+// hasPrefix tells whether term starts with prefix, whatever the way prefix is typed (see vietnamese.Variants)
+func hasPrefix(term, prefix string) bool {
+	variants := vietnamese.Variants(prefix)
+	if len(variants) == 0 {
+		return true
+	}
+
+	term = vietnamese.Normalize(term)
+	for _, variant := range variants {
+		if strings.HasPrefix(term, variant) {
+			return true
+		}
+	}
+	return false
+}
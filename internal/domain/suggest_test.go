@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_searchHistory(t *testing.T) {
+	h := NewSearchHistory(filepath.Join(t.TempDir(), "searches.json"))
+
+	terms, err := h.Terms()
+	require.NoError(t, err)
+	require.Empty(t, terms)
+
+	for _, term := range []string{"lạc trôi", " ", "nơi này có anh", "Lạc Trôi"} {
+		require.NoError(t, h.Record(term))
+	}
+	terms, err = h.Terms()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Lạc Trôi", "nơi này có anh"}, terms)
+}
+
+type mockSuggester struct {
+	mockConnector
+}
+
+func (m *mockSuggester) Suggest(prefix string) ([]string, error) {
+	called := m.Called(prefix)
+	return called.Get(0).([]string), called.Error(1)
+}
+
+func Test_app_Suggest(t *testing.T) {
+	storage := NewStorage(t.TempDir())
+	a := &mockSuggester{mockConnector{name: "a"}}
+	a.On("Search", mock.Anything).Return([]Song{{Id: "1", Name: "Lạc trôi", Connector: "a"}}, nil)
+	a.On("Suggest", "lacj").Return([]string{"lạc trôi", "lạc trôi remix"}, nil)
+
+	app := NewApp(Preferences{}, nil, nil, storage, a)
+	_, err := app.Search("a", "lạc trôi")
+	require.NoError(t, err)
+	_, err = app.Alternatives(Song{Name: "Nơi này có anh", Connector: "b"})
+	require.NoError(t, err)
+
+	got, err := app.Suggest("lacj")
+	require.NoError(t, err)
+	require.Equal(t, []string{"lạc trôi", "lạc trôi remix"}, got)
+
+	// the past searches only, the internal ones are not recorded
+	got, err = app.Suggest("")
+	require.NoError(t, err)
+	require.Equal(t, []string{"lạc trôi"}, got)
+	a.AssertNotCalled(t, "Suggest", "")
+}
+
+func Test_connectorZingMp3_Suggest(t *testing.T) {
+	mhc := &mockHttpClient{}
+	mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/v1/search/core/get/suggestion" && req.URL.Query().Get("keyword") == "lac"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"err":0,"msg":"Success","data":{"items":[{"keyword":"lạc trôi"},{"keyword":"lạc nhau có phải muôn đời"}]}}`)),
+	}, nil)
+
+	got, err := NewConnectorZingMp3(mhc).Suggest("lac")
+	require.NoError(t, err)
+	require.Equal(t, []string{"lạc trôi", "lạc nhau có phải muôn đời"}, got)
+	mhc.AssertExpectations(t)
+}
@@ -152,19 +152,65 @@ func (c *connectorZingMp3) api(u url.URL, respStruct interface{}) error {
 }
 
 type artistResp struct {
+	Id   string `json:"id"`
 	Name string `json:"name"`
 }
 
+// songResp holds the fields shared by the songs of every response
+type songResp struct {
+	Id         int64        `json:"id"`
+	Title      string       `json:"title"`
+	Artists    []artistResp `json:"artists"`
+	Duration   int64        `json:"duration"`
+	Thumbnail  string       `json:"thumbnail"`
+	ThumbnailM string       `json:"thumbnailM"`
+	IsExplicit bool         `json:"isExplicit"`
+	// unix time in seconds
+	ReleaseDate int64 `json:"releaseDate"`
+	Album       *struct {
+		EncodeId string `json:"encodeId"`
+		Title    string `json:"title"`
+	} `json:"album"`
+	Genres []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+}
+
+func (s songResp) toSong(connector string) Song {
+	song := Song{
+		Id:        strconv.FormatInt(s.Id, 10),
+		Name:      s.Title,
+		Duration:  utils.SecondsToDuration(s.Duration),
+		Connector: connector,
+		Thumbnail: s.ThumbnailM,
+		Explicit:  s.IsExplicit,
+	}
+	for _, artist := range s.Artists {
+		song.ArtistList = append(song.ArtistList, Artist{Id: artist.Id, Name: artist.Name})
+	}
+	song.Artists = JoinArtists(song.ArtistList)
+	if song.Thumbnail == "" {
+		song.Thumbnail = s.Thumbnail
+	}
+	if s.ReleaseDate > 0 {
+		song.Year = time.Unix(s.ReleaseDate, 0).UTC().Year()
+	}
+	if s.Album != nil && s.Album.Title != "" {
+		song.Album = &Album{Id: s.Album.EncodeId, Name: s.Album.Title}
+	}
+	for _, genre := range s.Genres {
+		song.Genres = append(song.Genres, genre.Name)
+	}
+	return song
+}
+
 type searchResp struct {
 	Err  int    `json:"err"`
 	Msg  string `json:"msg"`
 	Data struct {
 		Items []struct {
-			Id         int64        `json:"id"`
-			Title      string       `json:"title"`
-			Artists    []artistResp `json:"artists"`
-			PlayStatus int          `json:"playStatus"`
-			Duration   int64        `json:"duration"`
+			songResp
+			PlayStatus int `json:"playStatus"`
 		} `json:"items"`
 		LastIndex int  `json:"lastIndex"`
 		IsMore    bool `json:"isMore"`
@@ -195,39 +241,85 @@ func (c *connectorZingMp3) Search(name string) ([]Song, error) {
 	// build result
 	res := make([]Song, len(resp.Data.Items))
 	for idx, item := range resp.Data.Items {
-		res[idx] = Song{
-			Id:        strconv.FormatInt(item.Id, 10),
-			Name:      item.Title,
-			Artists:   collectArtists(item.Artists),
-			Duration:  time.Duration(item.Duration) * time.Second,
-			Connector: c.Name(),
-		}
+		res[idx] = item.toSong(c.Name())
 	}
 	return res, nil
 }
 
-func collectArtists(artists []artistResp) string {
-	ret := make([]string, len(artists))
-	for idx, artist := range artists {
-		ret[idx] = artist.Name
+type suggestionResp struct {
+	Err  int    `json:"err"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Items []struct {
+			Keyword string `json:"keyword"`
+		} `json:"items"`
+	} `json:"data"`
+}
+
+// Suggest returns the keywords zing mp3 completes prefix with
+func (c *connectorZingMp3) Suggest(prefix string) ([]string, error) {
+	// build the url containing query params and sig
+	q := make(url.Values)
+	q.Set("keyword", prefix)
+	u := c.makeUrl("/v1/search/core/get/suggestion", q)
+
+	// send request then decode response
+	var resp suggestionResp
+	if err := c.api(u, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// validate response
+	if resp.Err != 0 {
+		return nil, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	// build result
+	keywords := make([]string, len(resp.Data.Items))
+	for idx, item := range resp.Data.Items {
+		keywords[idx] = item.Keyword
 	}
-	return strings.Join(ret, ", ")
+	return keywords, nil
 }
 
 type getStreamingResp struct {
 	Err  int    `json:"err"`
 	Msg  string `json:"msg"`
 	Data struct {
-		Id       int64             `json:"id"`
-		Title    string            `json:"title"`
-		Artists  []artistResp      `json:"artists"`
-		Src      map[string]string `json:"src"`
-		Duration int64             `json:"duration"`
+		songResp
+		Src map[string]string `json:"src"`
 	} `json:"data"`
 	STime int64 `json:"sTime"`
 }
 
 func (c *connectorZingMp3) GetStreamingUrl(id string) (StreamableSong, error) {
+	resp, u, err := c.getSong(id)
+	if err != nil {
+		return StreamableSong{}, err
+	}
+
+	// validate response
+	if resp.Data.Src == nil || resp.Data.Src["128"] == "" {
+		return StreamableSong{}, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	return StreamableSong{
+		Song:         resp.Data.toSong(c.Name()),
+		StreamingUrl: resp.Data.Src["128"],
+	}, nil
+}
+
+// GetDetails returns the details of a song, even the ones whose 128 stream is VIP only
+func (c *connectorZingMp3) GetDetails(id string) (Song, error) {
+	resp, _, err := c.getSong(id)
+	if err != nil {
+		return Song{}, err
+	}
+	return resp.Data.toSong(c.Name()), nil
+}
+
+// getSong fetches the details and the streams of a song
+func (c *connectorZingMp3) getSong(id string) (getStreamingResp, url.URL, error) {
 	// build the url containing query params and sig
 	q := make(url.Values)
 	q.Set("id", id)
@@ -236,22 +328,213 @@ func (c *connectorZingMp3) GetStreamingUrl(id string) (StreamableSong, error) {
 	// send request then decode response
 	var resp getStreamingResp
 	if err := c.api(u, &resp); err != nil {
-		return StreamableSong{}, errors.WithStack(err)
+		return getStreamingResp{}, u, errors.WithStack(err)
 	}
 
 	// validate response
-	if resp.Err != 0 || resp.Data.Src == nil || resp.Data.Src["128"] == "" {
-		return StreamableSong{}, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	if resp.Err != 0 {
+		return getStreamingResp{}, u, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
 	}
+	return resp, u, nil
+}
 
-	return StreamableSong{
-		Song: Song{
-			Id:        strconv.FormatInt(resp.Data.Id, 10),
-			Name:      resp.Data.Title,
-			Artists:   collectArtists(resp.Data.Artists),
-			Duration:  utils.SecondsToDuration(resp.Data.Duration),
-			Connector: c.Name(),
-		},
-		StreamingUrl: resp.Data.Src["128"],
-	}, nil
+type getPlaylistResp struct {
+	Err  int    `json:"err"`
+	Msg  string `json:"msg"`
+	Data struct {
+		EncodeId string `json:"encodeId"`
+		Title    string `json:"title"`
+		Song     struct {
+			Items []songResp `json:"items"`
+		} `json:"song"`
+	} `json:"data"`
+}
+
+func (c *connectorZingMp3) GetPlaylist(id string) (Playlist, error) {
+	// build the url containing query params and sig
+	q := make(url.Values)
+	q.Set("id", id)
+	u := c.makeUrl("/v1/playlist/core/get/detail", q)
+
+	// send request then decode response
+	var resp getPlaylistResp
+	if err := c.api(u, &resp); err != nil {
+		return Playlist{}, errors.WithStack(err)
+	}
+
+	// validate response
+	if resp.Err != 0 {
+		return Playlist{}, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	// build result
+	songs := make([]Song, len(resp.Data.Song.Items))
+	for idx, item := range resp.Data.Song.Items {
+		songs[idx] = item.toSong(c.Name())
+	}
+	return Playlist{Name: resp.Data.Title, Songs: songs}, nil
+}
+
+type getLyricsResp struct {
+	Err  int    `json:"err"`
+	Msg  string `json:"msg"`
+	Data struct {
+		File      string `json:"file"`
+		Sentences []struct {
+			Words []struct {
+				StartTime int64  `json:"startTime"`
+				Data      string `json:"data"`
+			} `json:"words"`
+		} `json:"sentences"`
+	} `json:"data"`
+}
+
+// GetLyrics returns the synced lyrics of a song, served either word by word or as a LRC file
+func (c *connectorZingMp3) GetLyrics(id string) (Lyrics, error) {
+	// build the url containing query params and sig
+	q := make(url.Values)
+	q.Set("id", id)
+	u := c.makeUrl("/v1/lyric/core/get/detail", q)
+
+	// send request then decode response
+	var resp getLyricsResp
+	if err := c.api(u, &resp); err != nil {
+		return Lyrics{}, errors.WithStack(err)
+	}
+
+	// validate response
+	if resp.Err != 0 {
+		return Lyrics{}, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	// build result, a sentence starts with its first word
+	if len(resp.Data.Sentences) > 0 {
+		lyrics := Lyrics{Synced: true}
+		for _, sentence := range resp.Data.Sentences {
+			if len(sentence.Words) == 0 {
+				continue
+			}
+			words := make([]string, len(sentence.Words))
+			for idx, w := range sentence.Words {
+				words[idx] = w.Data
+			}
+			lyrics.Lines = append(lyrics.Lines, LyricsLine{
+				Time: time.Duration(sentence.Words[0].StartTime) * time.Millisecond,
+				Text: strings.Join(words, " "),
+			})
+		}
+		return lyrics, nil
+	}
+	if resp.Data.File != "" {
+		return fetchLRC(c.httpClient, resp.Data.File)
+	}
+	return Lyrics{}, errors.Errorf("song %s has no lyrics", id)
+}
+
+type getRecommendResp struct {
+	Err  int    `json:"err"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Items []songResp `json:"items"`
+	} `json:"data"`
+}
+
+// Recommend returns the songs zing mp3 plays after song
+func (c *connectorZingMp3) Recommend(song Song) ([]Song, error) {
+	// build the url containing query params and sig
+	q := make(url.Values)
+	q.Set("id", song.Id)
+	u := c.makeUrl("/v1/song/core/get/recommend", q)
+
+	// send request then decode response
+	var resp getRecommendResp
+	if err := c.api(u, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// validate response
+	if resp.Err != 0 {
+		return nil, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	// build result
+	songs := make([]Song, len(resp.Data.Items))
+	for idx, item := range resp.Data.Items {
+		songs[idx] = item.toSong(c.Name())
+	}
+	return songs, nil
+}
+
+// zingWeekCharts maps the regions to the ids of their weekly charts
+var zingWeekCharts = map[string]string{
+	"vn": "IWZ9Z08I",
+	"us": "IWZ9Z0BW",
+	"kr": "IWZ9Z0BO",
+}
+
+type getChartResp struct {
+	Err  int    `json:"err"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Title string     `json:"title"`
+		Items []songResp `json:"items"`
+	} `json:"data"`
+}
+
+// GetChart returns #zingchart, the realtime chart of Vietnam, or the weekly chart of the other regions.
+// The new releases are published for Vietnam only
+func (c *connectorZingMp3) GetChart(kind ChartKind, region string) (Chart, error) {
+	var u url.URL
+	name := ""
+	switch {
+	case kind == ChartTop && region == "vn":
+		u, name = c.makeUrl("/v1/chart/core/get/home", make(url.Values)), "#zingchart"
+	case kind == ChartTop && zingWeekCharts[region] != "":
+		q := make(url.Values)
+		q.Set("id", zingWeekCharts[region])
+		u = c.makeUrl("/v1/chart/core/get/week", q)
+	case kind == ChartNewReleases && region == "vn":
+		u = c.makeUrl("/v1/chart/core/get/new-release", make(url.Values))
+	default:
+		return Chart{}, errors.Errorf("no %s chart for region %s", kind, region)
+	}
+
+	// send request then decode response
+	var resp getChartResp
+	if err := c.api(u, &resp); err != nil {
+		return Chart{}, errors.WithStack(err)
+	}
+
+	// validate response
+	if resp.Err != 0 {
+		return Chart{}, errors.Errorf("got unexpected response for url %s: %+v", u.String(), resp)
+	}
+
+	// build result
+	if name == "" {
+		name = resp.Data.Title
+	}
+	chart := Chart{Name: name, Kind: kind, Region: region, Songs: make([]Song, len(resp.Data.Items))}
+	for idx, item := range resp.Data.Items {
+		chart.Songs[idx] = item.toSong(c.Name())
+	}
+	return chart, nil
+}
+
+// ResolveLink recognizes urls such as https://zingmp3.vn/bai-hat/Name/ZWAFE8BC.html
+// or https://zingmp3.vn/album/Name/ZWZB969E.html
+func (c *connectorZingMp3) ResolveLink(u *url.URL) (Link, bool) {
+	if !matchHost(u.Host, "zingmp3.vn") {
+		return Link{}, false
+	}
+
+	kind, id := linkPath(u)
+	switch kind {
+	case "bai-hat":
+		return Link{Kind: LinkSong, Connector: c.Name(), Id: id}, id != ""
+	case "album", "playlist":
+		return Link{Kind: LinkPlaylist, Connector: c.Name(), Id: id}, id != ""
+	default:
+		return Link{}, false
+	}
 }
@@ -1,8 +1,11 @@
 package domain
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -79,3 +82,143 @@ func Test_connectorZingMp3_makeUrl(t *testing.T) {
 		})
 	}
 }
+
+func Test_connectorZingMp3_GetLyrics(t *testing.T) {
+	mhc := &mockHttpClient{}
+	mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/v1/lyric/core/get/detail" && req.URL.Query().Get("id") == "ZWAFE8BC"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body: io.NopCloser(strings.NewReader(`{"err":0,"msg":"Success","data":{"sentences":[
+			{"words":[{"startTime":12500,"endTime":13000,"data":"Người"},{"startTime":13000,"endTime":13500,"data":"theo"}]},
+			{"words":[]},
+			{"words":[{"startTime":18000,"endTime":18500,"data":"Làn"}]}]}}`)),
+	}, nil)
+
+	c := NewConnectorZingMp3(mhc)
+	got, err := c.GetLyrics("ZWAFE8BC")
+	require.NoError(t, err)
+	require.Equal(t, Lyrics{Synced: true, Lines: []LyricsLine{
+		{Time: 12500 * time.Millisecond, Text: "Người theo"},
+		{Time: 18 * time.Second, Text: "Làn"},
+	}}, got)
+	mhc.AssertExpectations(t)
+}
+
+func Test_connectorZingMp3_GetChart(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     ChartKind
+		region   string
+		path     string
+		id       string
+		wantName string
+	}{
+		{"zingchart", ChartTop, "vn", "/v1/chart/core/get/home", "", "#zingchart"},
+		{"weekly chart", ChartTop, "kr", "/v1/chart/core/get/week", "IWZ9Z0BO", "Tuần 24"},
+		{"new releases", ChartNewReleases, "vn", "/v1/chart/core/get/new-release", "", "Tuần 24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mhc := &mockHttpClient{}
+			mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.URL.Path == tt.path && req.URL.Query().Get("id") == tt.id
+			})).Return(&http.Response{
+				StatusCode: 200,
+				Body: io.NopCloser(strings.NewReader(`{"err":0,"msg":"Success","data":{"title":"Tuần 24",
+					"items":[{"id":1073816610,"title":"Lạc Trôi","artists":[{"id":"IWZ98609","name":"Sơn Tùng M-TP"}],"duration":233}]}}`)),
+			}, nil)
+
+			got, err := NewConnectorZingMp3(mhc).GetChart(tt.kind, tt.region)
+			require.NoError(t, err)
+			require.Equal(t, Chart{Name: tt.wantName, Kind: tt.kind, Region: tt.region, Songs: []Song{{
+				Id:         "1073816610",
+				Name:       "Lạc Trôi",
+				Artists:    "Sơn Tùng M-TP",
+				Duration:   233 * time.Second,
+				Connector:  "zmp3",
+				ArtistList: []Artist{{Id: "IWZ98609", Name: "Sơn Tùng M-TP"}},
+			}}}, got)
+			mhc.AssertExpectations(t)
+		})
+	}
+
+	_, err := NewConnectorZingMp3(&mockHttpClient{}).GetChart(ChartNewReleases, "us")
+	require.Error(t, err)
+}
+
+func Test_connectorZingMp3_Recommend(t *testing.T) {
+	mhc := &mockHttpClient{}
+	mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Path == "/v1/song/core/get/recommend" && req.URL.Query().Get("id") == "1073816610"
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body: io.NopCloser(strings.NewReader(`{"err":0,"msg":"Success","data":{
+			"items":[{"id":1074729245,"title":"Nơi Này Có Anh","artists":[{"id":"IWZ98609","name":"Sơn Tùng M-TP"}],"duration":260}]}}`)),
+	}, nil)
+
+	got, err := NewConnectorZingMp3(mhc).Recommend(Song{Id: "1073816610", Connector: "zmp3"})
+	require.NoError(t, err)
+	require.Equal(t, []Song{{
+		Id:         "1074729245",
+		Name:       "Nơi Này Có Anh",
+		Artists:    "Sơn Tùng M-TP",
+		Duration:   260 * time.Second,
+		Connector:  "zmp3",
+		ArtistList: []Artist{{Id: "IWZ98609", Name: "Sơn Tùng M-TP"}},
+	}}, got)
+	mhc.AssertExpectations(t)
+}
+
+func Test_connectorZingMp3_GetDetails_without_128_stream(t *testing.T) {
+	body := `{"err":0,"msg":"Success","data":{"id":1073816610,"title":"Lạc Trôi",
+		"artists":[{"id":"IWZ98609","name":"Sơn Tùng M-TP"}],"duration":233,"src":{"320":"VIP"}}}`
+	mhc := &mockHttpClient{}
+	for i := 0; i < 2; i++ {
+		mhc.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Path == "/v1/song/core/get/detail" && req.URL.Query().Get("id") == "ZWAFE8BC"
+		})).Return(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil).Once()
+	}
+
+	c := NewConnectorZingMp3(mhc)
+	_, err := c.GetStreamingUrl("ZWAFE8BC")
+	require.Error(t, err)
+
+	got, err := c.GetDetails("ZWAFE8BC")
+	require.NoError(t, err)
+	require.Equal(t, Song{
+		Id:         "1073816610",
+		Name:       "Lạc Trôi",
+		Artists:    "Sơn Tùng M-TP",
+		Duration:   233 * time.Second,
+		Connector:  "zmp3",
+		ArtistList: []Artist{{Id: "IWZ98609", Name: "Sơn Tùng M-TP"}},
+	}, got)
+	mhc.AssertExpectations(t)
+}
+
+func Test_songResp_toSong(t *testing.T) {
+	var resp getStreamingResp
+	require.NoError(t, json.Unmarshal([]byte(`{"err":0,"data":{"id":1073816610,"title":"Lạc Trôi",
+		"artists":[{"id":"IWZ98609","name":"Sơn Tùng M-TP"},{"id":"IWZ9Z0BW","name":"Triple D"}],
+		"duration":233,"thumbnail":"https://zmp3/s.jpg","thumbnailM":"https://zmp3/m.jpg","isExplicit":true,
+		"releaseDate":1483462800,"album":{"encodeId":"ZOW9B6U0","title":"Lạc Trôi (Single)"},
+		"genres":[{"name":"Việt Nam"},{"name":"V-Pop"}],"src":{"128":"https://stream"}}}`), &resp))
+
+	require.Equal(t, Song{
+		Id:        "1073816610",
+		Name:      "Lạc Trôi",
+		Artists:   "Sơn Tùng M-TP, Triple D",
+		Duration:  233 * time.Second,
+		Connector: "zmp3",
+		ArtistList: []Artist{
+			{Id: "IWZ98609", Name: "Sơn Tùng M-TP"},
+			{Id: "IWZ9Z0BW", Name: "Triple D"},
+		},
+		Album:     &Album{Id: "ZOW9B6U0", Name: "Lạc Trôi (Single)"},
+		Year:      2017,
+		Genres:    []string{"Việt Nam", "V-Pop"},
+		Thumbnail: "https://zmp3/m.jpg",
+		Explicit:  true,
+	}, resp.Data.toSong("zmp3"))
+}
@@ -0,0 +1,185 @@
+// Package playlistfile reads and writes the playlist files shared with other players such as VLC or mpv
+package playlistfile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatM3U8 Format = "m3u8"
+	FormatXSPF Format = "xspf"
+)
+
+// Entry is a track of a playlist file
+type Entry struct {
+	Location string // url, path or song uri
+	Title    string
+	Artists  string
+	Duration time.Duration
+}
+
+// ParseFormat accepts a format name, case insensitive
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "m3u", "m3u8":
+		return FormatM3U8, nil
+	case "xspf":
+		return FormatXSPF, nil
+	default:
+		return "", errors.Errorf("playlist format %s not recognized, expected m3u8 or xspf", s)
+	}
+}
+
+// DetectFormat guesses the format of a playlist file from its name, which can be a path or an url
+func DetectFormat(name string) (Format, error) {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	return ParseFormat(strings.TrimPrefix(path.Ext(name), "."))
+}
+
+func Encode(w io.Writer, format Format, title string, entries []Entry) error {
+	switch format {
+	case FormatM3U8:
+		return encodeM3U8(w, entries)
+	case FormatXSPF:
+		return encodeXSPF(w, title, entries)
+	default:
+		return errors.Errorf("playlist format %s not recognized", format)
+	}
+}
+
+func Decode(r io.Reader, format Format) ([]Entry, error) {
+	switch format {
+	case FormatM3U8:
+		return decodeM3U8(r)
+	case FormatXSPF:
+		return decodeXSPF(r)
+	default:
+		return nil, errors.Errorf("playlist format %s not recognized", format)
+	}
+}
+
+func encodeM3U8(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	for _, e := range entries {
+		seconds := -1
+		if e.Duration > 0 {
+			seconds = int(e.Duration.Round(time.Second) / time.Second)
+		}
+		title := e.Title
+		if e.Artists != "" {
+			title = e.Artists + " - " + e.Title
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", seconds, title)
+		fmt.Fprintln(bw, e.Location)
+	}
+	return errors.Wrap(bw.Flush(), "error writing m3u8 playlist")
+}
+
+func decodeM3U8(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var info Entry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info = parseExtInf(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#"):
+			// other directives and comments
+		default:
+			info.Location = line
+			entries = append(entries, info)
+			info = Entry{}
+		}
+	}
+	return entries, errors.Wrap(scanner.Err(), "error reading m3u8 playlist")
+}
+
+// parseExtInf parses "<seconds>,<artists> - <title>", the attributes some players add before the comma are ignored
+func parseExtInf(s string) Entry {
+	var e Entry
+	parts := strings.SplitN(s, ",", 2)
+	if fields := strings.Fields(parts[0]); len(fields) > 0 {
+		if seconds, err := strconv.Atoi(fields[0]); err == nil && seconds > 0 {
+			e.Duration = time.Duration(seconds) * time.Second
+		}
+	}
+	if len(parts) == 2 {
+		e.Title = strings.TrimSpace(parts[1])
+		if i := strings.Index(e.Title, " - "); i >= 0 {
+			e.Artists, e.Title = e.Title[:i], e.Title[i+3:]
+		}
+	}
+	return e
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Duration int64  `xml:"duration,omitempty"` // milliseconds
+}
+
+func encodeXSPF(w io.Writer, title string, entries []Entry) error {
+	playlist := xspfPlaylist{Version: "1", Title: title}
+	for _, e := range entries {
+		playlist.Tracks = append(playlist.Tracks, xspfTrack{
+			Location: e.Location,
+			Title:    e.Title,
+			Creator:  e.Artists,
+			Duration: e.Duration.Milliseconds(),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "error writing xspf playlist")
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(playlist); err != nil {
+		return errors.Wrap(err, "error writing xspf playlist")
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.Wrap(err, "error writing xspf playlist")
+}
+
+func decodeXSPF(r io.Reader) ([]Entry, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&playlist); err != nil {
+		return nil, errors.Wrap(err, "error reading xspf playlist")
+	}
+
+	entries := make([]Entry, 0, len(playlist.Tracks))
+	for _, t := range playlist.Tracks {
+		entries = append(entries, Entry{
+			Location: strings.TrimSpace(t.Location),
+			Title:    t.Title,
+			Artists:  t.Creator,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package playlistfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var entries = []Entry{
+	{Location: "zmp3:ZWAFE8BC", Title: "Lạc trôi", Artists: "Sơn Tùng M-TP", Duration: 233 * time.Second},
+	{Location: "https://example.com/song.mp3", Title: "song"},
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"list.m3u8", FormatM3U8, false},
+		{"/tmp/list.M3U", FormatM3U8, false},
+		{"https://example.com/list.xspf?token=1", FormatXSPF, false},
+		{"list.pls", "", true},
+		{"list", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.name)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncode_M3U8(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Encode(&out, FormatM3U8, "morning", entries))
+	require.Equal(t, `#EXTM3U
+#EXTINF:233,Sơn Tùng M-TP - Lạc trôi
+zmp3:ZWAFE8BC
+#EXTINF:-1,song
+https://example.com/song.mp3
+`, out.String())
+}
+
+func TestDecode_M3U8(t *testing.T) {
+	got, err := Decode(strings.NewReader(`#EXTM3U
+# a comment
+#EXTINF:233 tvg-id="1",Sơn Tùng M-TP - Lạc trôi
+zmp3:ZWAFE8BC
+
+/music/local.mp3
+`), FormatM3U8)
+	require.NoError(t, err)
+	require.Equal(t, []Entry{
+		entries[0],
+		{Location: "/music/local.mp3"},
+	}, got)
+}
+
+func TestEncode_Decode_XSPF(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Encode(&out, FormatXSPF, "morning", entries))
+	require.Contains(t, out.String(), `<playlist xmlns="http://xspf.org/ns/0/" version="1">`)
+	require.Contains(t, out.String(), `<duration>233000</duration>`)
+
+	got, err := Decode(&out, FormatXSPF)
+	require.NoError(t, err)
+	require.Equal(t, entries, got)
+}
+
+func TestDecode_invalid_XSPF(t *testing.T) {
+	_, err := Decode(strings.NewReader("#EXTM3U"), FormatXSPF)
+	require.Error(t, err)
+}
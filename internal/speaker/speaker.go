@@ -0,0 +1,63 @@
+// Package speaker plays the songs through the default sound device,
+// it requires the audio libraries of the system (ALSA on linux) unlike the other sinks
+package speaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+	"github.com/pkg/errors"
+	"io.github.binatory/budich-cli/internal/domain"
+)
+
+const bufferPeriod = time.Second / 10
+
+type sink struct {
+	sampleRate beep.SampleRate
+	once       sync.Once
+	err        error
+}
+
+// NewSink creates a sink playing through the default sound device
+func NewSink(sampleRate beep.SampleRate) domain.Sink {
+	return &sink{sampleRate: sampleRate}
+}
+
+func (s *sink) init() error {
+	s.once.Do(func() {
+		s.err = speaker.Init(s.sampleRate, s.sampleRate.N(bufferPeriod))
+	})
+	return s.err
+}
+
+func (s *sink) SampleRate() beep.SampleRate {
+	return s.sampleRate
+}
+
+func (s *sink) Play(streamer beep.Streamer) error {
+	if err := s.init(); err != nil {
+		return errors.Wrap(err, "error initializing speaker")
+	}
+	speaker.Play(streamer)
+	return nil
+}
+
+func (s *sink) Lock() {
+	speaker.Lock()
+}
+
+func (s *sink) Unlock() {
+	speaker.Unlock()
+}
+
+func (s *sink) Close() error {
+	s.once.Do(func() {
+		s.err = errors.New("speaker sink closed")
+	})
+	if s.err == nil {
+		speaker.Close()
+	}
+	return nil
+}
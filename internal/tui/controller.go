@@ -1,41 +1,148 @@
 package tui
 
 import (
+	"io.github.binatory/budich-cli/internal/coverart"
 	"io.github.binatory/budich-cli/internal/domain"
 	"io.github.binatory/budich-cli/internal/tui/model"
+	"io.github.binatory/budich-cli/internal/utils"
+	"net/http"
+	"path/filepath"
 	"time"
 )
 
+var sleepPresets = []domain.SleepTimer{
+	{Mode: domain.SleepOff},
+	{Mode: domain.SleepAfterTime, After: 15 * time.Minute},
+	{Mode: domain.SleepAfterTime, After: 30 * time.Minute},
+	{Mode: domain.SleepAfterTime, After: time.Hour},
+	{Mode: domain.SleepAfterSong},
+}
+
 type controller struct {
-	app   domain.App
-	model *model.Model
-	view  *view
+	app         domain.App
+	queue       domain.Queue
+	model       *model.Model
+	view        *view
+	sleepPreset int
+	covers      coverart.Cache // nil when there is no place to cache them
 }
 
 func New(app domain.App) *controller {
 	c := &controller{
 		app:   app,
-		model: model.New(app.ConnectorNames()),
+		queue: domain.NewQueue(app),
+		model: model.New(app.ConnectorNames(), app.Preferences()),
+	}
+	if dir, err := utils.DataDir(); err == nil {
+		c.covers = coverart.NewCache(filepath.Join(dir, "covers"), &http.Client{Timeout: app.Preferences().HttpTimeout})
 	}
 
-	v := NewView(c.model, c.onSelectSong, c.switchPage, c.onPauseOrResume, c.onSearch)
+	v := NewView(c.model, handlers{
+		onSelectSong:     c.onSelectSong,
+		onSwitchPage:     c.switchPage,
+		onPauseOrResume:  c.onPauseOrResume,
+		onSearch:         c.onSearch,
+		onSuggest:        c.onSuggest,
+		onCycleSleep:     c.onCycleSleep,
+		onToggleAutoplay: c.onToggleAutoplay,
+		onSeek:           c.onSeek,
+		onCycleLoop:      c.onCycleLoop,
+		onAddBookmark:    c.onAddBookmark,
+		onSelectBookmark: c.onSelectBookmark,
+		onChangeVolume:   c.onChangeVolume,
+		onSelectSource:   c.onSelectSource,
+		onFilterLibrary:  c.onFilterLibrary,
+		onPlayLibrary:    c.onPlayLibrary,
+		onCreatePlaylist: c.onCreatePlaylist,
+		onAddToPlaylist:  c.onAddToPlaylist,
+		onToggleFavorite: c.onToggleFavorite,
+		onOpenUrl:        c.onOpenUrl,
+		onLoadChart:      c.onLoadChart,
+		onPlayChart:      c.onPlayChart,
+	})
 	c.view = v
 
+	c.restoreSession()
 	go c.WatchPlayer()
 
 	return c
 }
 
 func (c *controller) Start() error {
-	return c.view.StartView()
+	err := c.view.StartView()
+	c.saveSession()
+	return err
+}
+
+func (c *controller) restoreSession() {
+	session, err := c.app.Storage().Session.Load()
+	if err != nil || !session.IsResumable() {
+		return
+	}
+
+	c.queue.Restore(session)
+	song := session.Songs[session.Index]
+	c.model.Player.IsInitialized = true
+	c.model.Player.SongName = song.Name
+	c.model.Player.ArtistsName = song.Artists
+	c.model.Player.Status.State = domain.StateStopped
+	c.model.Player.Status.Pos = session.Pos
+}
+
+func (c *controller) saveSession() {
+	// nothing worth saving before anything has been played or restored
+	if len(c.queue.Songs()) == 0 {
+		return
+	}
+	c.app.Storage().Session.Save(c.queue.Snapshot())
 }
 
 func (c *controller) PollPlayer() {
 	c.model.Player.Lock()
 	defer c.model.Player.Unlock()
 
-	if c.model.Player.Underlying != nil {
-		c.model.Player.Status = c.model.Player.Underlying.Report()
+	if c.model.Player.IsInitialized {
+		report := c.queue.Report()
+		if report.Playing >= 0 {
+			// the queue may have moved on to another song or source since one was selected
+			c.model.Player.Status = report.Player
+			c.model.Player.SongName = report.Player.Song.Name
+			c.model.Player.ArtistsName = report.Player.Song.Artists
+			c.model.Player.Alternative = report.Alternative
+		}
+		if thumbnail := c.model.Player.Status.Song.Thumbnail; thumbnail != c.model.Player.CoverUrl {
+			c.model.Player.CoverUrl, c.model.Player.Cover = thumbnail, nil
+			if thumbnail != "" && c.covers != nil {
+				go c.onLoadCover(thumbnail)
+			}
+		}
+		c.model.Player.Sleep = report.Sleep
+		c.model.Player.Volume = report.Volume
+		c.model.Player.Autoplay = report.Autoplay
+		c.view.updatePlayerView(true)
+	}
+
+	// follow the queue, the player lock is held so the lyrics are fetched aside
+	if c.model.CurrentPage == model.PageLyrics {
+		if song := c.model.Player.Status.Song.Song; song.Id != "" && c.model.Lyrics.Follow(song.Ref()) {
+			go c.onFetchLyrics(song)
+		}
+		c.view.updateLyricsView(true)
+	}
+}
+
+func (c *controller) onLoadCover(url string) {
+	img, err := c.covers.Get(url)
+	if err != nil {
+		return
+	}
+
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+
+	// another song may be playing by now
+	if c.model.Player.CoverUrl == url {
+		c.model.Player.Cover = img
 		c.view.updatePlayerView(true)
 	}
 }
@@ -47,11 +154,23 @@ func (c *controller) onSearch() {
 		return
 	}
 
-	c.model.SongsList = songs
+	c.model.SongsList = domain.RankSongs(c.model.Search.Term, songs)
 	c.switchPage(model.PageList)
 }
 
-func (c *controller) onSelectSong(song domain.Song) {
+// onSuggest fetches the completions of prefix, they are dropped when the term changed meanwhile
+func (c *controller) onSuggest(prefix string) {
+	suggestions, err := c.app.Suggest(prefix)
+	if err != nil || c.model.Search.Term != prefix {
+		return
+	}
+
+	c.model.Search.Suggestions, c.model.Search.SuggestionsFor = suggestions, prefix
+	c.view.updateSuggestions()
+}
+
+// onSelectSong plays the song found along with its alternatives, which are played when it fails
+func (c *controller) onSelectSong(song domain.SongGroup) {
 	c.model.Player.Lock()
 	defer c.model.Player.Unlock()
 
@@ -59,45 +178,375 @@ func (c *controller) onSelectSong(song domain.Song) {
 	defer c.view.updateViewsAsync()
 
 	player := &c.model.Player
-
-	if player.Underlying != nil {
-		player.Underlying.Stop()
-	}
-
 	player.IsInitialized = true
 	player.SongName = song.Name
 	player.ArtistsName = song.Artists
+	player.LoopMarked = false
 
-	underlying, err := c.app.Play(song.Id, song.Connector)
-	if err != nil {
+	c.queue.AddGroups(song)
+	if err := c.queue.Play(len(c.queue.Songs()) - 1); err != nil {
 		player.Status.State = domain.StateError
-		return
 	}
-	player.Underlying = underlying
-	go underlying.Start()
 }
 
 func (c *controller) switchPage(page model.PageEnum) {
+	switch page {
+	case model.PageBookmarks:
+		c.loadBookmarks()
+	case model.PageLibrary:
+		c.loadLibrary()
+	case model.PageLyrics:
+		c.loadLyrics()
+	case model.PageCharts:
+		c.loadCharts()
+	}
+
 	c.model.CurrentPage = page
 	c.view.updateViewsAsync()
 }
 
+func (c *controller) onSeek(delta time.Duration) {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+
+	pos := c.model.Player.Status.Pos + delta
+	if pos < 0 {
+		pos = 0
+	}
+	c.queue.Seek(pos)
+}
+
+// onCycleLoop marks point A, then point B which starts looping, then stops looping
+func (c *controller) onCycleLoop() {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+	defer c.view.updatePlayerView(true)
+
+	player := &c.model.Player
+	switch {
+	case !player.Status.Loop.IsEmpty():
+		if err := c.queue.SetLoop(domain.Segment{}); err == nil {
+			player.Status.Loop = domain.Segment{}
+		}
+	case !player.LoopMarked:
+		player.LoopMarked, player.LoopA = true, player.Status.Pos
+	default:
+		loop := domain.Segment{Start: player.LoopA, End: player.Status.Pos}
+		player.LoopMarked = false
+		if err := c.queue.SetLoop(loop); err == nil {
+			player.Status.Loop = loop
+		}
+	}
+}
+
+func (c *controller) loadBookmarks() {
+	c.model.Player.RLock()
+	song := c.model.Player.Status.Song.Song
+	c.model.Player.RUnlock()
+
+	bookmarks, err := c.app.Storage().Bookmarks.List(song)
+	if err != nil {
+		// TODO show error modal
+		bookmarks = nil
+	}
+	c.model.Bookmarks.Song = song
+	c.model.Bookmarks.Items = bookmarks
+}
+
+func (c *controller) onAddBookmark() {
+	c.model.Player.RLock()
+	pos := c.model.Player.Status.Pos
+	c.model.Player.RUnlock()
+
+	if c.model.Bookmarks.Song.Id == "" {
+		return
+	}
+
+	bookmark := domain.Bookmark{Name: c.model.Bookmarks.Name, Pos: pos}
+	if err := c.app.Storage().Bookmarks.Save(c.model.Bookmarks.Song, bookmark); err != nil {
+		// TODO show error modal
+		return
+	}
+
+	c.model.Bookmarks.Name = ""
+	c.loadBookmarks()
+	c.view.updateViewsAsync()
+}
+
+func (c *controller) onSelectBookmark(bookmark domain.Bookmark) {
+	c.queue.Seek(bookmark.Pos)
+}
+
+func (c *controller) loadLibrary() {
+	library := c.app.Storage().Library
+	lm := &c.model.Library
+
+	lm.Sources = []string{model.FavoritesSource}
+	if playlists, err := library.Playlists(); err == nil {
+		for _, p := range playlists {
+			lm.Sources = append(lm.Sources, p.Name)
+		}
+	}
+
+	var err error
+	if source := lm.SelectedSource(); source == model.FavoritesSource {
+		lm.Songs, err = library.Favorites()
+	} else {
+		var playlist domain.Playlist
+		playlist, err = library.Playlist(source)
+		lm.Songs = playlist.Songs
+	}
+	if err != nil {
+		// TODO show error modal
+		lm.Songs = nil
+	}
+	if lm.Filter != "" {
+		lm.Songs = domain.FilterSongs(lm.Songs, lm.Filter)
+	}
+}
+
+// loadLyrics fetches the lyrics of the song being played, unless they are already loaded
+func (c *controller) loadLyrics() {
+	song, ok := c.currentSong()
+	if !ok {
+		c.model.Lyrics.Fail("No song is playing")
+		return
+	}
+	if c.model.Lyrics.Follow(song.Ref()) {
+		c.fetchLyrics(song)
+	}
+}
+
+func (c *controller) fetchLyrics(song domain.Song) {
+	lyrics, err := c.app.Lyrics(song.Ref())
+	c.model.Lyrics.Set(song.Ref(), lyrics, err)
+}
+
+func (c *controller) onFetchLyrics(song domain.Song) {
+	c.fetchLyrics(song)
+	c.view.updateViewsAsync()
+}
+
+func (c *controller) onSelectSource(index int) {
+	if index == c.model.Library.Selected {
+		return
+	}
+
+	c.model.Library.Selected = index
+	c.loadLibrary()
+	c.view.updateViewsAsync()
+}
+
+func (c *controller) onFilterLibrary() {
+	c.loadLibrary()
+	c.view.updateViewsAsync()
+}
+
+// onPlayLibrary queues every song of the selected source and plays from the one at index
+func (c *controller) onPlayLibrary(index int) {
+	c.playSongs(c.model.Library.Songs, index)
+}
+
+// playSongs queues songs and plays from the one at index
+func (c *controller) playSongs(songs []domain.Song, index int) {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+	defer c.view.updateViewsAsync()
+
+	if index < 0 || index >= len(songs) {
+		return
+	}
+
+	player := &c.model.Player
+	player.IsInitialized = true
+	player.SongName = songs[index].Name
+	player.ArtistsName = songs[index].Artists
+	player.LoopMarked = false
+
+	offset := len(c.queue.Songs())
+	c.queue.Add(songs...)
+	if err := c.queue.Play(offset + index); err != nil {
+		player.Status.State = domain.StateError
+	}
+}
+
+func (c *controller) onCreatePlaylist() {
+	name := c.model.Library.PlaylistName
+	if err := c.app.Storage().Library.CreatePlaylist(name); err != nil {
+		// TODO show error modal
+		return
+	}
+
+	c.model.Library.PlaylistName = ""
+	c.loadLibrary()
+	c.view.updateViewsAsync()
+}
+
+// onAddToPlaylist adds the current song to the selected playlist
+func (c *controller) onAddToPlaylist() {
+	song, ok := c.currentSong()
+	source := c.model.Library.SelectedSource()
+	if !ok || source == model.FavoritesSource {
+		return
+	}
+
+	if err := c.app.Storage().Library.AddToPlaylist(source, song); err != nil {
+		// TODO show error modal
+		return
+	}
+
+	c.loadLibrary()
+	c.view.updateViewsAsync()
+}
+
+func (c *controller) onToggleFavorite() {
+	song, ok := c.currentSong()
+	if !ok {
+		return
+	}
+
+	library := c.app.Storage().Library
+	isFavorite, err := library.IsFavorite(song)
+	if err == nil {
+		if isFavorite {
+			err = library.RemoveFavorite(song)
+		} else {
+			err = library.AddFavorite(song)
+		}
+	}
+	if err != nil {
+		// TODO show error modal
+		return
+	}
+
+	if c.model.CurrentPage == model.PageLibrary {
+		c.loadLibrary()
+		c.view.updateViewsAsync()
+	}
+}
+
+// onOpenUrl plays the song or the playlist a share url points to
+func (c *controller) onOpenUrl() {
+	openUrl := &c.model.OpenUrl
+	openUrl.Err = ""
+	defer c.view.updateViewsAsync()
+
+	link, err := c.app.ResolveLink(openUrl.Url)
+	if err != nil {
+		openUrl.Err = err.Error()
+		return
+	}
+
+	var songs []domain.Song
+	switch link.Kind {
+	case domain.LinkSong:
+		song, err := c.app.Song(domain.SongRef{Connector: link.Connector, Id: link.Id})
+		if err != nil {
+			openUrl.Err = err.Error()
+			return
+		}
+		songs = []domain.Song{song.Song}
+	case domain.LinkPlaylist:
+		playlist, err := c.app.Playlist(domain.PlaylistSource{Connector: link.Connector, Id: link.Id})
+		if err != nil {
+			openUrl.Err = err.Error()
+			return
+		}
+		songs = playlist.Songs
+	}
+
+	openUrl.Url = ""
+	c.playSongs(songs, 0)
+}
+
+// loadCharts fetches the selected chart the first time the page is shown
+func (c *controller) loadCharts() {
+	if c.model.Charts.Chart.Songs == nil && c.model.Charts.Err == "" {
+		c.fetchChart()
+	}
+}
+
+func (c *controller) fetchChart() {
+	cm := &c.model.Charts
+	chart, err := c.app.Chart(cm.Connector(), cm.Kind(), cm.Region())
+	if err != nil {
+		cm.Chart, cm.Err = domain.Chart{}, err.Error()
+		return
+	}
+	cm.Chart, cm.Err = chart, ""
+}
+
+func (c *controller) onLoadChart() {
+	c.fetchChart()
+	c.view.updateViewsAsync()
+}
+
+// onPlayChart queues the whole chart and plays from the song at index
+func (c *controller) onPlayChart(index int) {
+	c.playSongs(c.model.Charts.Chart.Songs, index)
+}
+
+func (c *controller) currentSong() (domain.Song, bool) {
+	c.model.Player.RLock()
+	defer c.model.Player.RUnlock()
+
+	song := c.model.Player.Status.Song.Song
+	return song, song.Id != ""
+}
+
 func (c *controller) onPauseOrResume() {
 	c.model.Player.Lock()
 	defer c.model.Player.Unlock()
 
-	if c.model.Player.Underlying != nil {
-		c.model.Player.Underlying.PauseOrResume()
+	// resume a restored or finished queue
+	if report := c.queue.Report(); !report.Running {
+		if report.Index < report.Len {
+			c.queue.Play(report.Index)
+		}
+		return
 	}
+
+	c.queue.PauseOrResume()
+}
+
+func (c *controller) onChangeVolume(delta int) {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+
+	c.queue.SetVolume(c.queue.Report().Volume + delta)
+	c.model.Player.Volume = c.queue.Report().Volume
+	c.view.updatePlayerView(true)
+}
+
+func (c *controller) onCycleSleep() {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+
+	c.sleepPreset = (c.sleepPreset + 1) % len(sleepPresets)
+	c.queue.Sleep(sleepPresets[c.sleepPreset])
+	c.model.Player.Sleep = c.queue.Report().Sleep
+	c.view.updatePlayerView(true)
+}
+
+func (c *controller) onToggleAutoplay() {
+	c.model.Player.Lock()
+	defer c.model.Player.Unlock()
+
+	c.queue.SetAutoplay(!c.queue.Report().Autoplay)
+	c.model.Player.Autoplay = c.queue.Report().Autoplay
+	c.view.updatePlayerView(true)
 }
 
 func (c *controller) WatchPlayer() {
 	ticker := time.Tick(500 * time.Millisecond)
+	saveTicker := time.Tick(10 * time.Second)
 
 	for {
 		select {
 		case <-ticker:
 			c.PollPlayer()
+		case <-saveTicker:
+			c.saveSession()
 		}
 	}
 }
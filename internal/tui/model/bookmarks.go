@@ -0,0 +1,9 @@
+package model
+
+import "io.github.binatory/budich-cli/internal/domain"
+
+type BookmarksModel struct {
+	Song  domain.Song
+	Items []domain.Bookmark
+	Name  string
+}
@@ -0,0 +1,40 @@
+package model
+
+import "io.github.binatory/budich-cli/internal/domain"
+
+type ChartsModel struct {
+	// readonly
+	ConnectorNames []string
+
+	SelectedConnector int
+	SelectedKind      int // index in domain.ChartKinds
+	SelectedRegion    int // index in domain.ChartRegions
+
+	Chart domain.Chart
+	Err   string
+}
+
+func newChartsModel(connectorNames []string, defaultConnector string) ChartsModel {
+	cm := ChartsModel{ConnectorNames: connectorNames}
+	for idx, name := range connectorNames {
+		if name == defaultConnector {
+			cm.SelectedConnector = idx
+		}
+	}
+	return cm
+}
+
+func (cm *ChartsModel) Connector() string {
+	if cm.SelectedConnector < 0 || cm.SelectedConnector >= len(cm.ConnectorNames) {
+		return ""
+	}
+	return cm.ConnectorNames[cm.SelectedConnector]
+}
+
+func (cm *ChartsModel) Kind() domain.ChartKind {
+	return domain.ChartKinds[cm.SelectedKind]
+}
+
+func (cm *ChartsModel) Region() string {
+	return domain.ChartRegions[cm.SelectedRegion]
+}
@@ -9,18 +9,25 @@ type Model struct {
 	sync.RWMutex
 	CurrentPage PageEnum
 	Search      SearchModel
-	SongsList   []domain.Song
+	SongsList   []domain.SongGroup
 	Player      PlayerModel
+	Bookmarks   BookmarksModel
+	Library     LibraryModel
+	OpenUrl     OpenUrlModel
+	Lyrics      LyricsModel
+	Charts      ChartsModel
 }
 
-func New(connectorsName []string) *Model {
+func New(connectorsName []string, prefs domain.Preferences) *Model {
 
 	return &Model{
 		CurrentPage: PageSearch,
 		Search: SearchModel{
-			ConnectorNames: connectorsName,
+			ConnectorNames:    connectorsName,
+			SelectedConnector: prefs.DefaultConnector,
 		},
 		SongsList: nil,
-		Player:    PlayerModel{},
+		Player:    PlayerModel{Volume: domain.DefaultVolume},
+		Charts:    newChartsModel(connectorsName, prefs.DefaultConnector),
 	}
 }
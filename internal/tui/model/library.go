@@ -0,0 +1,23 @@
+package model
+
+import "io.github.binatory/budich-cli/internal/domain"
+
+// FavoritesSource is the name of the library source listing the favorite songs
+const FavoritesSource = "Favorites"
+
+type LibraryModel struct {
+	// favorites first, then every playlist
+	Sources  []string
+	Selected int
+	Songs    []domain.Song
+	Filter   string // keeps the songs of the source matching it only
+
+	PlaylistName string
+}
+
+func (lm *LibraryModel) SelectedSource() string {
+	if lm.Selected < 0 || lm.Selected >= len(lm.Sources) {
+		return FavoritesSource
+	}
+	return lm.Sources[lm.Selected]
+}
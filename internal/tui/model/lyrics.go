@@ -0,0 +1,51 @@
+package model
+
+import (
+	"io.github.binatory/budich-cli/internal/domain"
+	"sync"
+)
+
+// LyricsModel is filled by the lyrics fetched aside while the view reads it, hence its lock
+type LyricsModel struct {
+	sync.RWMutex
+
+	// the song the lyrics belong to, they are loaded again when another one plays
+	Song   domain.SongRef
+	Lyrics domain.Lyrics
+	Err    string
+}
+
+// Follow clears the lyrics unless they belong to song already, it tells whether the lyrics of song must be fetched
+func (lm *LyricsModel) Follow(song domain.SongRef) bool {
+	lm.Lock()
+	defer lm.Unlock()
+
+	if song == lm.Song {
+		return false
+	}
+	lm.Song, lm.Lyrics, lm.Err = song, domain.Lyrics{}, ""
+	return true
+}
+
+// Fail clears the lyrics and shows err instead
+func (lm *LyricsModel) Fail(err string) {
+	lm.Lock()
+	defer lm.Unlock()
+
+	lm.Song, lm.Lyrics, lm.Err = domain.SongRef{}, domain.Lyrics{}, err
+}
+
+// Set stores the lyrics fetched for song, they are dropped when another song is followed meanwhile
+func (lm *LyricsModel) Set(song domain.SongRef, lyrics domain.Lyrics, err error) {
+	lm.Lock()
+	defer lm.Unlock()
+
+	if song != lm.Song {
+		return
+	}
+	if err != nil {
+		lm.Err = err.Error()
+		return
+	}
+	lm.Lyrics = lyrics
+}
@@ -0,0 +1,6 @@
+package model
+
+type OpenUrlModel struct {
+	Url string
+	Err string
+}
@@ -4,8 +4,13 @@ package model
 type PageEnum string
 
 const (
-	PageList   PageEnum = "PageList"
-	PageSearch PageEnum = "PageSearch"
+	PageList      PageEnum = "PageList"
+	PageSearch    PageEnum = "PageSearch"
+	PageBookmarks PageEnum = "PageBookmarks"
+	PageLibrary   PageEnum = "PageLibrary"
+	PageOpenUrl   PageEnum = "PageOpenUrl"
+	PageLyrics    PageEnum = "PageLyrics"
+	PageCharts    PageEnum = "PageCharts"
 )
 
 func (pe PageEnum) String() string {
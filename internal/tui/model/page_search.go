@@ -7,4 +7,18 @@ type SearchModel struct {
 	Term              string
 	SelectedConnector string
 	SelectedType      string
+
+	// completions of the term SuggestionsFor, shown under the term field
+	Suggestions    []string
+	SuggestionsFor string
+}
+
+// ConnectorIndex returns the index of the selected connector, the first one when none is selected
+func (sm *SearchModel) ConnectorIndex() int {
+	for idx, name := range sm.ConnectorNames {
+		if name == sm.SelectedConnector {
+			return idx
+		}
+	}
+	return 0
 }
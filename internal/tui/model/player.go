@@ -1,8 +1,10 @@
 package model
 
 import (
+	"image"
 	"io.github.binatory/budich-cli/internal/domain"
 	"sync"
+	"time"
 )
 
 type PlayerModel struct {
@@ -10,6 +12,19 @@ type PlayerModel struct {
 	IsInitialized bool
 	SongName      string
 	ArtistsName   string
-	Underlying    domain.Player
 	Status        domain.PlayerStatus
+	Sleep         domain.SleepStatus
+	Volume        int
+	Autoplay      bool
+
+	// the song is played from another connector since the queued one failed
+	Alternative bool
+
+	// cover art of the song, nil until downloaded
+	CoverUrl string
+	Cover    image.Image
+
+	// point A of the A-B loop being marked
+	LoopMarked bool
+	LoopA      time.Duration
 }
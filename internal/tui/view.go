@@ -4,65 +4,216 @@ import (
 	"fmt"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"io.github.binatory/budich-cli/internal/coverart"
 	"io.github.binatory/budich-cli/internal/domain"
 	"io.github.binatory/budich-cli/internal/tui/model"
+	"io.github.binatory/budich-cli/internal/utils"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	seekStep   = 10 * time.Second
+	volumeStep = 5
+
+	// size of the cover art in cells, square in most terminals
+	coverCols = 16
+	coverRows = 8
+)
+
+type handlers struct {
+	onSelectSong     func(domain.SongGroup)
+	onSwitchPage     func(model.PageEnum)
+	onPauseOrResume  func()
+	onSearch         func()
+	onSuggest        func(prefix string)
+	onCycleSleep     func()
+	onToggleAutoplay func()
+	onSeek           func(delta time.Duration)
+	onCycleLoop      func()
+	onAddBookmark    func()
+	onSelectBookmark func(domain.Bookmark)
+	onChangeVolume   func(delta int)
+	onSelectSource   func(index int)
+	onFilterLibrary  func()
+	onPlayLibrary    func(index int)
+	onCreatePlaylist func()
+	onAddToPlaylist  func()
+	onToggleFavorite func()
+	onOpenUrl        func()
+	onLoadChart      func()
+	onPlayChart      func(index int)
+}
+
 type view struct {
-	model           *model.Model
-	onSelectSong    func(domain.Song)
-	onSwitchPage    func(model.PageEnum)
-	onPauseOrResume func()
-	onSearch        func()
+	model *model.Model
+	handlers
 
 	// ui components
-	appView        *tview.Application
-	playerView     *tview.TextView
-	searchFormView *tview.Form
-	pagesView      *tview.Pages
-	songsListView  *tview.Table
+	appView           *tview.Application
+	playerView        *tview.TextView
+	coverView         *tview.TextView
+	bottomView        *tview.Flex
+	gridView          *tview.Grid
+	searchFormView    *tview.Form
+	searchTermView    *tview.InputField
+	pagesView         *tview.Pages
+	songsListView     *tview.Table
+	bookmarksView     *tview.Flex
+	bookmarksFormView *tview.Form
+	bookmarksListView *tview.Table
+	libraryView       *tview.Flex
+	libraryFormView   *tview.Form
+	libraryListView   *tview.Table
+	openUrlView       *tview.Flex
+	openUrlFormView   *tview.Form
+	openUrlErrView    *tview.TextView
+	lyricsView        *tview.TextView
+	chartsView        *tview.Flex
+	chartsFormView    *tview.Form
+	chartsTitleView   *tview.TextView
+	chartsListView    *tview.Table
+
+	coverProtocol coverart.Protocol
+	// the cover rendered in coverView, and where it has been drawn with the graphics protocols
+	coverUrl   string
+	coverDrawn string
 }
 
-func NewView(m *model.Model, onSelectSong func(domain.Song), onSwitchPage func(enum model.PageEnum), onPauseOrResume func(), onSearch func()) *view {
+func NewView(m *model.Model, h handlers) *view {
 	return &view{
-		model:           m,
-		onSelectSong:    onSelectSong,
-		onSwitchPage:    onSwitchPage,
-		onPauseOrResume: onPauseOrResume,
-		onSearch:        onSearch,
+		model:    m,
+		handlers: h,
 	}
 }
 
 func (v *view) StartView() error {
 	v.songsListView = tview.NewTable().SetBorders(false).SetSelectable(true, false)
 	v.songsListView.SetSelectedFunc(func(row, _ int) {
-		song := v.songsListView.GetCell(row, 0).GetReference().(domain.Song)
+		song := v.songsListView.GetCell(row, 0).GetReference().(domain.SongGroup)
 		go v.onSelectSong(song)
 	})
 
 	v.playerView = tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	v.coverView = tview.NewTextView().SetDynamicColors(true)
+	v.coverProtocol = coverart.DetectProtocol(os.Getenv)
+	v.bottomView = tview.NewFlex().
+		AddItem(v.coverView, 0, 0, false).
+		AddItem(v.playerView, 0, 1, false)
 
 	v.searchFormView = tview.NewForm()
 
+	v.bookmarksFormView = tview.NewForm().SetHorizontal(true)
+	v.bookmarksListView = tview.NewTable().SetBorders(false).SetSelectable(true, false)
+	v.bookmarksListView.SetSelectedFunc(func(row, _ int) {
+		bookmark := v.bookmarksListView.GetCell(row, 0).GetReference().(domain.Bookmark)
+		go v.onSelectBookmark(bookmark)
+	})
+	v.bookmarksView = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.bookmarksFormView, 3, 0, true).
+		AddItem(v.bookmarksListView, 0, 1, false)
+
+	v.libraryFormView = tview.NewForm().SetHorizontal(true)
+	v.libraryListView = tview.NewTable().SetBorders(false).SetSelectable(true, false)
+	v.libraryListView.SetSelectedFunc(func(row, _ int) {
+		go v.onPlayLibrary(row - 1)
+	})
+	v.libraryView = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.libraryFormView, 3, 0, true).
+		AddItem(v.libraryListView, 0, 1, false)
+
+	v.openUrlFormView = tview.NewForm()
+	v.openUrlErrView = tview.NewTextView().SetTextColor(tcell.ColorRed)
+	v.openUrlView = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.openUrlFormView, 5, 0, true).
+		AddItem(v.openUrlErrView, 0, 1, false)
+
+	v.lyricsView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+
+	v.chartsFormView = tview.NewForm().SetHorizontal(true)
+	v.chartsTitleView = tview.NewTextView().SetDynamicColors(true)
+	v.chartsListView = tview.NewTable().SetBorders(false).SetSelectable(true, false)
+	v.chartsListView.SetSelectedFunc(func(row, _ int) {
+		go v.onPlayChart(row - 1)
+	})
+	v.chartsView = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.chartsFormView, 3, 0, true).
+		AddItem(v.chartsTitleView, 1, 0, false).
+		AddItem(v.chartsListView, 0, 1, false)
+
 	v.pagesView = tview.NewPages()
 	v.pagesView.AddPage(model.PageSearch.String(), v.searchFormView, true, true)
 	v.pagesView.AddPage(model.PageList.String(), v.songsListView, true, false)
+	v.pagesView.AddPage(model.PageBookmarks.String(), v.bookmarksView, true, false)
+	v.pagesView.AddPage(model.PageLibrary.String(), v.libraryView, true, false)
+	v.pagesView.AddPage(model.PageOpenUrl.String(), v.openUrlView, true, false)
+	v.pagesView.AddPage(model.PageLyrics.String(), v.lyricsView, true, false)
+	v.pagesView.AddPage(model.PageCharts.String(), v.chartsView, true, false)
 
-	grid := tview.NewGrid().
+	v.gridView = tview.NewGrid().
 		SetRows(0, 3).
 		SetBorders(true).
 		AddItem(v.pagesView, 0, 0, 1, 1, 0, 0, false).
-		AddItem(v.playerView, 1, 0, 1, 1, 0, 0, false)
+		AddItem(v.bottomView, 1, 0, 1, 1, 0, 0, false)
 
 	v.appView = tview.NewApplication()
 	v.appView.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
 		switch ev.Key() {
+		case tcell.KeyF2:
+			go v.onToggleFavorite()
+			return nil
+		case tcell.KeyF3:
+			go v.onSwitchPage(model.PageLibrary)
+			return nil
 		case tcell.KeyF4:
 			go v.onSwitchPage(model.PageSearch)
 			return nil
+		case tcell.KeyF5:
+			go v.onCycleLoop()
+			return nil
+		case tcell.KeyF6:
+			go v.onSwitchPage(model.PageOpenUrl)
+			return nil
+		case tcell.KeyF7:
+			go v.onCycleSleep()
+			return nil
+		case tcell.KeyF8:
+			go v.onSwitchPage(model.PageBookmarks)
+			return nil
+		case tcell.KeyLeft, tcell.KeyRight:
+			if ev.Modifiers()&tcell.ModCtrl == 0 {
+				return ev
+			}
+			delta := seekStep
+			if ev.Key() == tcell.KeyLeft {
+				delta = -seekStep
+			}
+			go v.onSeek(delta)
+			return nil
+		case tcell.KeyUp, tcell.KeyDown:
+			if ev.Modifiers()&tcell.ModCtrl == 0 {
+				return ev
+			}
+			delta := volumeStep
+			if ev.Key() == tcell.KeyDown {
+				delta = -volumeStep
+			}
+			go v.onChangeVolume(delta)
+			return nil
 		case tcell.KeyF9:
 			go v.onPauseOrResume()
 			return nil
+		case tcell.KeyF10:
+			go v.onSwitchPage(model.PageLyrics)
+			return nil
+		case tcell.KeyF11:
+			go v.onSwitchPage(model.PageCharts)
+			return nil
+		case tcell.KeyF12:
+			go v.onToggleAutoplay()
+			return nil
 			//case tcell.KeyRune:
 			//	switch ev.Rune() {
 			//	case 'p':
@@ -73,7 +224,8 @@ func (v *view) StartView() error {
 		return ev
 	})
 
-	v.appView.SetRoot(grid, true)
+	v.appView.SetAfterDrawFunc(v.drawCoverGraphics)
+	v.appView.SetRoot(v.gridView, true)
 	v.updateViews()
 
 	return v.appView.Run()
@@ -85,6 +237,11 @@ func (v *view) updaters() []func(bool) {
 		v.updatePlayerView,
 		v.switchPage,
 		v.updateSongsListView,
+		v.updateBookmarksView,
+		v.updateLibraryView,
+		v.updateOpenUrlView,
+		v.updateLyricsView,
+		v.updateChartsView,
 	}
 }
 
@@ -108,12 +265,15 @@ func (v *view) updateSearchFormView(async bool) {
 		v.searchFormView.AddDropDown("Type", []string{"Song", "Artist", "Playlist"}, 0, func(option string, _ int) {
 			v.model.Search.SelectedType = option
 		})
-		v.searchFormView.AddDropDown("Connector", v.model.Search.ConnectorNames, 0, func(option string, _ int) {
+		v.searchFormView.AddDropDown("Connector", v.model.Search.ConnectorNames, v.model.Search.ConnectorIndex(), func(option string, _ int) {
 			v.model.Search.SelectedConnector = option
 		})
-		v.searchFormView.AddInputField("Term", "", 20, nil, func(term string) {
+		v.searchTermView = tview.NewInputField().SetLabel("Term").SetFieldWidth(20)
+		v.searchTermView.SetChangedFunc(func(term string) {
 			v.model.Search.Term = term
 		})
+		v.searchTermView.SetAutocompleteFunc(v.autocompleteTerm)
+		v.searchFormView.AddFormItem(v.searchTermView)
 		v.searchFormView.AddButton("Search", func() {
 			go v.onSearch()
 		})
@@ -125,6 +285,30 @@ func (v *view) updateSearchFormView(async bool) {
 	})
 }
 
+// autocompleteTerm shows the suggestions once fetched, the field text is the term being typed
+func (v *view) autocompleteTerm(text string) []string {
+	search := &v.model.Search
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if text != search.SuggestionsFor {
+		// the changed func sets the term after the autocompletion, the fetched suggestions must not be dropped
+		search.Term = text
+		go v.onSuggest(text)
+		return nil
+	}
+	return search.Suggestions
+}
+
+// updateSuggestions shows the suggestions fetched for the term being typed
+func (v *view) updateSuggestions() {
+	v.executeUpdate(true, func() {
+		if v.searchTermView != nil {
+			v.searchTermView.Autocomplete()
+		}
+	})
+}
+
 func (v *view) executeUpdate(async bool, f func()) {
 	if async {
 		v.appView.QueueUpdateDraw(f)
@@ -137,14 +321,81 @@ func (v *view) updatePlayerView(async bool) {
 	v.executeUpdate(async, func() {
 		if v.model.Player.IsInitialized {
 			playerModel := &v.model.Player
-			v.playerView.SetText(fmt.Sprintf("%s - %s\nCurrent state (%s): %s/%s",
-				playerModel.SongName, playerModel.ArtistsName, playerModel.Status.State, playerModel.Status.Pos, playerModel.Status.Len))
+			v.playerView.SetText(fmt.Sprintf("%s - %s%s\nCurrent state (%s): %s/%s | Vol %d%%%s%s%s",
+				playerModel.SongName, playerModel.ArtistsName, formatSource(playerModel), playerModel.Status.State, playerModel.Status.Pos, playerModel.Status.Len,
+				playerModel.Volume, formatLoop(playerModel), formatSleep(playerModel.Sleep), formatAutoplay(playerModel.Autoplay)))
 		} else {
 			v.playerView.SetText("N/A")
 		}
+		v.updateCoverView()
 	})
 }
 
+// updateCoverView makes room for the cover art next to the player when there is one
+func (v *view) updateCoverView() {
+	// the cover is downloaded aside
+	v.model.Player.RLock()
+	cover, url := v.model.Player.Cover, v.model.Player.CoverUrl
+	v.model.Player.RUnlock()
+	if cover == nil {
+		url = ""
+	}
+	if url == v.coverUrl {
+		return
+	}
+	v.coverUrl = url
+
+	if cover == nil {
+		v.gridView.SetRows(0, 3)
+		v.bottomView.ResizeItem(v.coverView, 0, 0)
+		v.coverView.SetText("")
+		return
+	}
+
+	v.gridView.SetRows(0, coverRows)
+	v.bottomView.ResizeItem(v.coverView, coverCols, 0)
+	if v.coverProtocol == coverart.ProtocolHalfBlocks {
+		v.coverView.SetText(coverart.HalfBlocks(cover, coverCols, coverRows))
+	} else {
+		// drawn over the empty view once the screen is drawn
+		v.coverView.SetText("")
+	}
+}
+
+// drawCoverGraphics draws the cover art with the sixel or kitty graphics once the screen is drawn,
+// again only when the cover or its place changed since the terminal keeps the image
+func (v *view) drawCoverGraphics(screen tcell.Screen) {
+	if v.coverProtocol == coverart.ProtocolHalfBlocks {
+		return
+	}
+
+	x, y, width, height := v.coverView.GetRect()
+	drawn := fmt.Sprintf("%s@%d,%d,%d,%d", v.coverUrl, x, y, width, height)
+	if drawn == v.coverDrawn {
+		return
+	}
+	v.coverDrawn = drawn
+
+	var sb strings.Builder
+	if v.coverProtocol == coverart.ProtocolKitty {
+		sb.WriteString(coverart.KittyDelete)
+	}
+	v.model.Player.RLock()
+	cover := v.model.Player.Cover
+	v.model.Player.RUnlock()
+	if v.coverUrl != "" && cover != nil && width > 0 && height > 0 {
+		var image string
+		if v.coverProtocol == coverart.ProtocolKitty {
+			image, _ = coverart.Kitty(cover, width, height)
+		} else {
+			image = coverart.Sixel(cover, width, height)
+		}
+		// save the cursor, move to the top left corner of the view, draw then restore the cursor
+		fmt.Fprintf(&sb, "\x1b7\x1b[%d;%dH%s\x1b8", y+1, x+1, image)
+	}
+	os.Stdout.WriteString(sb.String())
+}
+
 func (v *view) updateSongsListView(async bool) {
 	if v.model.CurrentPage != model.PageList {
 		return
@@ -155,7 +406,7 @@ func (v *view) updateSongsListView(async bool) {
 		v.songsListView.Clear()
 
 		// set headers
-		headers := []string{"Id", "Name", "Artists", "Duration"}
+		headers := []string{"Id", "Name", "Artists", "Duration", "Other sources"}
 		v.songsListView.SetFixed(1, len(headers))
 		for col, header := range headers {
 			v.songsListView.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignCenter).SetSelectable(false))
@@ -163,7 +414,11 @@ func (v *view) updateSongsListView(async bool) {
 
 		// set data
 		for row, song := range v.model.SongsList {
-			for col, text := range []string{song.Id, song.Name, song.Artists, song.Duration.String()} {
+			alternatives := make([]string, 0, len(song.Alternatives))
+			for _, a := range song.Alternatives {
+				alternatives = append(alternatives, a.Ref().String())
+			}
+			for col, text := range []string{song.Ref().String(), song.Name, song.Artists, song.Duration.String(), strings.Join(alternatives, ", ")} {
 				cell := tview.NewTableCell(text).SetTextColor(tcell.ColorWhite)
 				if col == 0 {
 					cell.SetReference(song)
@@ -174,9 +429,232 @@ func (v *view) updateSongsListView(async bool) {
 	})
 }
 
+func (v *view) updateBookmarksView(async bool) {
+	if v.model.CurrentPage != model.PageBookmarks {
+		return
+	}
+
+	v.executeUpdate(async, func() {
+		bookmarks := &v.model.Bookmarks
+
+		v.bookmarksFormView.Clear(true)
+		v.bookmarksFormView.AddInputField("Name", bookmarks.Name, 20, nil, func(name string) {
+			bookmarks.Name = name
+		})
+		v.bookmarksFormView.AddButton("Add at current position", func() {
+			go v.onAddBookmark()
+		})
+
+		v.bookmarksListView.Clear()
+		headers := []string{"Name", "Position"}
+		v.bookmarksListView.SetFixed(1, len(headers))
+		for col, header := range headers {
+			v.bookmarksListView.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignCenter).SetSelectable(false))
+		}
+		for row, bookmark := range bookmarks.Items {
+			for col, text := range []string{bookmark.Name, utils.FormatTimestamp(bookmark.Pos)} {
+				cell := tview.NewTableCell(text).SetTextColor(tcell.ColorWhite)
+				if col == 0 {
+					cell.SetReference(bookmark)
+				}
+				v.bookmarksListView.SetCell(row+1, col, cell)
+			}
+		}
+	})
+}
+
+func (v *view) updateLibraryView(async bool) {
+	if v.model.CurrentPage != model.PageLibrary {
+		return
+	}
+
+	v.executeUpdate(async, func() {
+		library := &v.model.Library
+
+		v.libraryFormView.Clear(true)
+		v.libraryFormView.AddDropDown("Source", library.Sources, library.Selected, func(_ string, index int) {
+			go v.onSelectSource(index)
+		})
+		v.libraryFormView.AddInputField("Filter", library.Filter, 20, nil, func(filter string) {
+			library.Filter = filter
+		})
+		v.libraryFormView.AddButton("Filter", func() {
+			go v.onFilterLibrary()
+		})
+		v.libraryFormView.AddButton("Add current song", func() {
+			go v.onAddToPlaylist()
+		})
+		v.libraryFormView.AddInputField("New playlist", library.PlaylistName, 20, nil, func(name string) {
+			library.PlaylistName = name
+		})
+		v.libraryFormView.AddButton("Create", func() {
+			go v.onCreatePlaylist()
+		})
+
+		v.libraryListView.Clear()
+		headers := []string{"Id", "Name", "Artists", "Duration"}
+		v.libraryListView.SetFixed(1, len(headers))
+		for col, header := range headers {
+			v.libraryListView.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignCenter).SetSelectable(false))
+		}
+		for row, song := range library.Songs {
+			for col, text := range []string{song.Ref().String(), song.Name, song.Artists, song.Duration.String()} {
+				v.libraryListView.SetCell(row+1, col, tview.NewTableCell(text).SetTextColor(tcell.ColorWhite))
+			}
+		}
+	})
+}
+
+func (v *view) updateOpenUrlView(async bool) {
+	if v.model.CurrentPage != model.PageOpenUrl {
+		return
+	}
+
+	v.executeUpdate(async, func() {
+		openUrl := &v.model.OpenUrl
+
+		v.openUrlFormView.Clear(true)
+		v.openUrlFormView.AddInputField("URL", openUrl.Url, 60, nil, func(u string) {
+			openUrl.Url = u
+		})
+		v.openUrlFormView.AddButton("Open", func() {
+			go v.onOpenUrl()
+		})
+		v.openUrlErrView.SetText(openUrl.Err)
+	})
+}
+
+// updateLyricsView highlights the line being sung and keeps it in the middle of the view
+func (v *view) updateLyricsView(async bool) {
+	if v.model.CurrentPage != model.PageLyrics {
+		return
+	}
+
+	v.executeUpdate(async, func() {
+		lyrics := &v.model.Lyrics
+		lyrics.RLock()
+		defer lyrics.RUnlock()
+
+		if lyrics.Err != "" {
+			v.lyricsView.SetText(tview.Escape(lyrics.Err))
+			return
+		}
+		if len(lyrics.Lyrics.Lines) == 0 {
+			v.lyricsView.SetText("Loading...")
+			return
+		}
+
+		current := lyrics.Lyrics.LineAt(v.model.Player.Status.Pos)
+		var sb strings.Builder
+		for idx, line := range lyrics.Lyrics.Lines {
+			if idx == current {
+				fmt.Fprintf(&sb, "[yellow::b]%s[-::-]\n", tview.Escape(line.Text))
+			} else {
+				fmt.Fprintf(&sb, "%s\n", tview.Escape(line.Text))
+			}
+		}
+		v.lyricsView.SetText(sb.String())
+
+		if current >= 0 {
+			_, _, _, height := v.lyricsView.GetInnerRect()
+			row := current - height/2
+			if row < 0 {
+				row = 0
+			}
+			v.lyricsView.ScrollTo(row, 0)
+		}
+	})
+}
+
+func (v *view) updateChartsView(async bool) {
+	if v.model.CurrentPage != model.PageCharts {
+		return
+	}
+
+	v.executeUpdate(async, func() {
+		charts := &v.model.Charts
+
+		kinds := make([]string, len(domain.ChartKinds))
+		for idx, kind := range domain.ChartKinds {
+			kinds[idx] = string(kind)
+		}
+
+		v.chartsFormView.Clear(true)
+		v.chartsFormView.AddDropDown("Connector", charts.ConnectorNames, charts.SelectedConnector, func(_ string, index int) {
+			charts.SelectedConnector = index
+		})
+		v.chartsFormView.AddDropDown("Chart", kinds, charts.SelectedKind, func(_ string, index int) {
+			charts.SelectedKind = index
+		})
+		v.chartsFormView.AddDropDown("Region", domain.ChartRegions, charts.SelectedRegion, func(_ string, index int) {
+			charts.SelectedRegion = index
+		})
+		v.chartsFormView.AddButton("Load", func() {
+			go v.onLoadChart()
+		})
+		v.chartsFormView.AddButton("Queue all", func() {
+			go v.onPlayChart(0)
+		})
+
+		if charts.Err != "" {
+			v.chartsTitleView.SetText("[red]" + tview.Escape(charts.Err))
+		} else {
+			v.chartsTitleView.SetText(tview.Escape(charts.Chart.Name))
+		}
+
+		v.chartsListView.Clear()
+		headers := []string{"#", "Id", "Name", "Artists", "Duration"}
+		v.chartsListView.SetFixed(1, len(headers))
+		for col, header := range headers {
+			v.chartsListView.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetAlign(tview.AlignCenter).SetSelectable(false))
+		}
+		for row, song := range charts.Chart.Songs {
+			for col, text := range []string{strconv.Itoa(row + 1), song.Ref().String(), song.Name, song.Artists, song.Duration.String()} {
+				v.chartsListView.SetCell(row+1, col, tview.NewTableCell(text).SetTextColor(tcell.ColorWhite))
+			}
+		}
+	})
+}
+
 func (v *view) switchPage(async bool) {
 	v.executeUpdate(async, func() {
 		v.pagesView.SwitchToPage(v.model.CurrentPage.String())
 		v.appView.SetFocus(v.pagesView)
 	})
 }
+
+// formatSource tells where the song is played from when the queued source failed
+func formatSource(player *model.PlayerModel) string {
+	if !player.Alternative {
+		return ""
+	}
+	return fmt.Sprintf(" (from %s)", player.Status.Song.Ref())
+}
+
+func formatLoop(player *model.PlayerModel) string {
+	if loop := player.Status.Loop; !loop.IsEmpty() {
+		return fmt.Sprintf(" | A-B %s-%s", utils.FormatTimestamp(loop.Start), utils.FormatTimestamp(loop.End))
+	}
+	if player.LoopMarked {
+		return fmt.Sprintf(" | A %s", utils.FormatTimestamp(player.LoopA))
+	}
+	return ""
+}
+
+func formatAutoplay(autoplay bool) string {
+	if !autoplay {
+		return ""
+	}
+	return " | Autoplay"
+}
+
+func formatSleep(sleep domain.SleepStatus) string {
+	switch sleep.Mode {
+	case domain.SleepAfterTime:
+		return fmt.Sprintf(" | Sleep in %s", sleep.Remaining)
+	case domain.SleepAfterSong:
+		return " | Stop after this song"
+	default:
+		return ""
+	}
+}
@@ -1,9 +1,18 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 func GetMapKeys(m interface{}) []string {
@@ -23,3 +32,120 @@ func GetMapKeys(m interface{}) []string {
 func SecondsToDuration(seconds int64) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
+
+// DataDir returns the directory holding the logs and the user data, creating it if needed
+func DataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "error finding home directory")
+	}
+
+	dir := filepath.Join(homeDir, ".budich-cli")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "error creating data directory %s", dir)
+	}
+	return dir, nil
+}
+
+// ConfigPath returns the path of the config file, under XDG_CONFIG_HOME when it is set and in the data directory otherwise
+func ConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "budich-cli", "config.yaml"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "error finding home directory")
+	}
+	return filepath.Join(homeDir, ".budich-cli", "config.yaml"), nil
+}
+
+// ReadJSON decodes the file at path into v, leaving v untouched if the file does not exist
+func ReadJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error reading %s", path)
+	}
+
+	return errors.Wrapf(json.Unmarshal(data, v), "error decoding %s", path)
+}
+
+// WriteJSON atomically replaces the file at path with v encoded as JSON
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "error encoding %s", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "error creating directory of %s", path)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrapf(err, "error writing %s", tmp)
+	}
+	return errors.Wrapf(os.Rename(tmp, path), "error replacing %s", path)
+}
+
+// ParseTimestamp parses positions such as 90, 1:30, 1:02:03 or 1m30s
+func ParseTimestamp(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return 0, errors.Errorf("invalid timestamp %s", s)
+		}
+		return d, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, errors.Errorf("invalid timestamp %s", s)
+	}
+
+	var d time.Duration
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, errors.Errorf("invalid timestamp %s", s)
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second, nil
+}
+
+// FormatTimestamp formats d as m:ss or h:mm:ss
+func FormatTimestamp(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, sec := int(d/time.Hour), int(d/time.Minute)%60, int(d/time.Second)%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}
+
+// ParseTime parses either a date (2006-01-02), a RFC 3339 time or a duration
+// before now such as 36h or 7d
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	var d time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n uint64
+		n, err = strconv.ParseUint(days, 10, 32)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d < 0 {
+		return time.Time{}, errors.Errorf("invalid time %s, expected a date (2006-01-02) or a duration (e.g. 7d)", s)
+	}
+	return now.Add(-d), nil
+}
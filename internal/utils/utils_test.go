@@ -3,6 +3,8 @@ package utils
 import (
 	"github.com/stretchr/testify/require"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -38,3 +40,90 @@ func TestSecondsToDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigPath(t *testing.T) {
+	if xdg, found := os.LookupEnv("XDG_CONFIG_HOME"); found {
+		defer os.Setenv("XDG_CONFIG_HOME", xdg)
+	} else {
+		defer os.Unsetenv("XDG_CONFIG_HOME")
+	}
+
+	require.NoError(t, os.Setenv("XDG_CONFIG_HOME", "/tmp/config"))
+	path, err := ConfigPath()
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/config/budich-cli/config.yaml", path)
+
+	require.NoError(t, os.Unsetenv("XDG_CONFIG_HOME"))
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	path, err = ConfigPath()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(home, ".budich-cli", "config.yaml"), path)
+}
+
+func TestReadJSON_WriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "data.json")
+
+	got := map[string]int{"untouched": 1}
+	require.NoError(t, ReadJSON(path, &got))
+	require.Equal(t, map[string]int{"untouched": 1}, got)
+
+	require.NoError(t, WriteJSON(path, map[string]int{"a": 1, "b": 2}))
+	got = nil
+	require.NoError(t, ReadJSON(path, &got))
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, got)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90", 90 * time.Second, false},
+		{"1:30", 90 * time.Second, false},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"1:2:3:4", 0, true},
+		{"-1:30", 0, true},
+		{"-5s", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.input)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	require.Equal(t, "0:00", FormatTimestamp(0))
+	require.Equal(t, "1:30", FormatTimestamp(90*time.Second))
+	require.Equal(t, "1:02:03", FormatTimestamp(time.Hour+2*time.Minute+3*time.Second+400*time.Millisecond))
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2021-06-01", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2021-06-01T08:30:00Z", time.Date(2021, 6, 1, 8, 30, 0, 0, time.UTC), false},
+		{"36h", time.Date(2021, 6, 14, 0, 0, 0, 0, time.UTC), false},
+		{"7d", time.Date(2021, 6, 8, 12, 0, 0, 0, time.UTC), false},
+		{"-7d", time.Time{}, true},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTime(tt.input, now)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.True(t, tt.want.Equal(got), "got %s", got)
+		})
+	}
+}
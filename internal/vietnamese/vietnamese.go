@@ -0,0 +1,317 @@
+// Package vietnamese normalizes vietnamese texts so that they can be matched
+// whatever the way they have been typed: with tone marks, without them, or with the Telex or VNI input methods
+package vietnamese
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tone int
+
+const (
+	toneNone  tone = iota
+	toneGrave      // huyền
+	toneAcute      // sắc
+	toneHook       // hỏi
+	toneTilde      // ngã
+	toneDot        // nặng
+)
+
+// vowels lists the forms of every vowel, indexed by tone
+var vowels = map[rune][]rune{
+	'a': []rune("aàáảãạ"),
+	'ă': []rune("ăằắẳẵặ"),
+	'â': []rune("âầấẩẫậ"),
+	'e': []rune("eèéẻẽẹ"),
+	'ê': []rune("êềếểễệ"),
+	'i': []rune("iìíỉĩị"),
+	'o': []rune("oòóỏõọ"),
+	'ô': []rune("ôồốổỗộ"),
+	'ơ': []rune("ơờớởỡợ"),
+	'u': []rune("uùúủũụ"),
+	'ư': []rune("ưừứửữự"),
+	'y': []rune("yỳýỷỹỵ"),
+}
+
+// bases maps every vowel form to its base vowel without any tone (ạ -> a, ậ -> â)
+// and plain maps it to its letter without any diacritic (ậ -> a)
+var bases, plain = make(map[rune]rune), map[rune]rune{'đ': 'd'}
+
+func init() {
+	for base, forms := range vowels {
+		letter := []rune(strings.NewReplacer("ă", "a", "â", "a", "ê", "e", "ô", "o", "ơ", "o", "ư", "u").Replace(string(base)))[0]
+		for _, form := range forms {
+			bases[form] = base
+			plain[form] = letter
+		}
+	}
+}
+
+// RemoveDiacritics strips the tone marks and the vowel diacritics, e.g. Lạc Trôi -> Lac Troi
+func RemoveDiacritics(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			// combining marks of decomposed texts
+			continue
+		}
+
+		lower := unicode.ToLower(r)
+		if p, found := plain[lower]; found {
+			if lower != r {
+				p = unicode.ToUpper(p)
+			}
+			r = p
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Normalize lower cases s, strips its diacritics and punctuation, e.g. "Lạc Trôi (Remix)" -> "lac troi remix"
+func Normalize(s string) string {
+	s = strings.ToLower(RemoveDiacritics(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Variants returns the normalized forms of a query, as typed and as decoded from Telex and VNI
+func Variants(query string) []string {
+	var variants []string
+	for _, v := range []string{Normalize(query), Normalize(DecodeTelex(query)), Normalize(DecodeVNI(query))} {
+		if v != "" && !contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+// Alternatives returns the other ways to write a query worth sending to a search engine,
+// the query decoded from Telex or VNI then the same without diacritics
+func Alternatives(query string) []string {
+	var alternatives []string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" || strings.EqualFold(s, query) {
+			return
+		}
+		for _, a := range alternatives {
+			if strings.EqualFold(a, s) {
+				return
+			}
+		}
+		alternatives = append(alternatives, s)
+	}
+
+	decoded := []string{DecodeTelex(query), DecodeVNI(query)}
+	for _, d := range decoded {
+		add(d)
+	}
+	add(RemoveDiacritics(query))
+	for _, d := range decoded {
+		add(RemoveDiacritics(d))
+	}
+	return alternatives
+}
+
+// Match tells whether every word of a variant of query is found in text
+func Match(text, query string) bool {
+	text = Normalize(text)
+	for _, variant := range Variants(query) {
+		if matchWords(text, variant) {
+			return true
+		}
+	}
+	return len(Variants(query)) == 0
+}
+
+func matchWords(text, query string) bool {
+	for _, word := range strings.Fields(query) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+var telexTones = map[rune]tone{'f': toneGrave, 's': toneAcute, 'r': toneHook, 'x': toneTilde, 'j': toneDot, 'z': toneNone}
+
+var telexReplacer = strings.NewReplacer(
+	"uow", "ươ",
+	"dd", "đ",
+	"aa", "â",
+	"aw", "ă",
+	"ee", "ê",
+	"oo", "ô",
+	"ow", "ơ",
+	"uw", "ư",
+)
+
+// DecodeTelex converts a text typed with the Telex input method, e.g. "lacj trooi" -> "lạc trôi"
+func DecodeTelex(s string) string {
+	return decodeWords(s, func(word []rune) []rune {
+		t := toneNone
+		hasTone := false
+		if last := word[len(word)-1]; len(word) > 1 && hasVowel(word[:len(word)-1]) {
+			if wt, found := telexTones[last]; found {
+				t, hasTone = wt, true
+				word = word[:len(word)-1]
+			}
+		}
+
+		word = []rune(telexReplacer.Replace(string(word)))
+		if hasTone {
+			word = applyTone(word, t)
+		}
+		return word
+	})
+}
+
+var vniTones = map[rune]tone{'0': toneNone, '1': toneAcute, '2': toneGrave, '3': toneHook, '4': toneTilde, '5': toneDot}
+
+// vniMarks maps the VNI diacritic keys to the letters they apply to
+var vniMarks = map[rune]map[rune]rune{
+	'6': {'a': 'â', 'e': 'ê', 'o': 'ô'},
+	'7': {'o': 'ơ', 'u': 'ư'},
+	'8': {'a': 'ă'},
+	'9': {'d': 'đ'},
+}
+
+// DecodeVNI converts a text typed with the VNI input method, e.g. "la5c tro6i" -> "lạc trôi"
+func DecodeVNI(s string) string {
+	return decodeWords(s, func(word []rune) []rune {
+		if !hasVowel(word) {
+			// numbers and the like
+			return word
+		}
+
+		t := toneNone
+		hasTone := false
+		var res []rune
+		for _, r := range word {
+			if vt, found := vniTones[r]; found {
+				t, hasTone = vt, true
+				continue
+			}
+			if marks, found := vniMarks[r]; found {
+				applied := false
+				for i := len(res) - 1; i >= 0; i-- {
+					if m, found := marks[res[i]]; found {
+						res[i] = m
+						applied = true
+						// the horn applies to both letters of ươ
+						if r == '7' && m == 'ơ' && i > 0 && res[i-1] == 'u' {
+							res[i-1] = 'ư'
+						}
+						break
+					}
+				}
+				if applied {
+					continue
+				}
+			}
+			res = append(res, r)
+		}
+
+		if hasTone {
+			res = applyTone(res, t)
+		}
+		return res
+	})
+}
+
+// decodeWords applies decode to every lower cased word of s
+func decodeWords(s string, decode func(word []rune) []rune) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		words[i] = string(decode([]rune(w)))
+	}
+	return strings.Join(words, " ")
+}
+
+func isVowel(r rune) bool {
+	_, found := bases[r]
+	return found
+}
+
+func hasVowel(word []rune) bool {
+	for _, r := range word {
+		if isVowel(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTone puts the tone t on the main vowel of word, replacing its previous tone if any
+func applyTone(word []rune, t tone) []rune {
+	start, end := vowelCluster(word)
+	if start < 0 {
+		return word
+	}
+
+	target := end - 1
+	switch {
+	case end-start == 1:
+		target = start
+	case hasModifiedVowel(word[start:end]):
+		// the tone goes to the vowel with a diacritic, the last one for ươ
+		for i := end - 1; i >= start; i-- {
+			if b := bases[word[i]]; b == 'ă' || b == 'â' || b == 'ê' || b == 'ô' || b == 'ơ' || b == 'ư' {
+				target = i
+				break
+			}
+		}
+	case end == len(word):
+		// no final consonant, e.g. mái, múa
+		target = end - 2
+	}
+
+	res := append([]rune(nil), word...)
+	res[target] = vowels[bases[res[target]]][t]
+	return res
+}
+
+// vowelCluster finds the main vowels of word, skipping the u of qu and the i of gi
+func vowelCluster(word []rune) (int, int) {
+	start := -1
+	for i, r := range word {
+		if !isVowel(r) {
+			if start >= 0 {
+				return start, i
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			if i == 1 && i+1 < len(word) && isVowel(word[i+1]) &&
+				((word[0] == 'q' && bases[r] == 'u') || (word[0] == 'g' && bases[r] == 'i')) {
+				start = i + 1
+			}
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	return start, len(word)
+}
+
+func hasModifiedVowel(cluster []rune) bool {
+	for _, r := range cluster {
+		if b := bases[r]; b == 'ă' || b == 'â' || b == 'ê' || b == 'ô' || b == 'ơ' || b == 'ư' {
+			return true
+		}
+	}
+	return false
+}
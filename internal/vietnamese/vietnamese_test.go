@@ -0,0 +1,90 @@
+package vietnamese
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveDiacritics(t *testing.T) {
+	require.Equal(t, "Lac troi - Son Tung M-TP", RemoveDiacritics("Lạc trôi - Sơn Tùng M-TP"))
+	require.Equal(t, "Duong DI", RemoveDiacritics("Đường ĐI"))
+	// decomposed form, as typed on some systems
+	require.Equal(t, "Lac troi", RemoveDiacritics("La\u0323c tro\u0302i"))
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "lac troi remix", Normalize("  Lạc Trôi (Remix) "))
+	require.Equal(t, "", Normalize("..."))
+}
+
+func TestDecodeTelex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"lacj trooi", "lạc trôi"},
+		{"Nowi nayf cos anh", "nơi này có anh"},
+		{"nguowif", "người"},
+		{"dduowngf", "đường"},
+		{"mais", "mái"},
+		{"quyeenr", "quyển"},
+		{"hello", "hello"},
+		{"2021", "2021"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			require.Equal(t, tt.want, DecodeTelex(tt.in))
+		})
+	}
+}
+
+func TestDecodeVNI(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"la5c tro6i", "lạc trôi"},
+		{"lac5 troi6", "lạc trôi"},
+		{"nguo72i", "người"},
+		{"d9uo7ng2", "đường"},
+		{"2021", "2021"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			require.Equal(t, tt.want, DecodeVNI(tt.in))
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	require.Equal(t, []string{"lacj trooi", "lac troi"}, Variants("lacj trooi"))
+	require.Equal(t, []string{"lac troi"}, Variants("Lạc trôi"))
+	require.Empty(t, Variants(" "))
+}
+
+func TestAlternatives(t *testing.T) {
+	require.Equal(t, []string{"lạc trôi", "lac troi"}, Alternatives("lacj trooi"))
+	require.Equal(t, []string{"Lac troi"}, Alternatives("Lạc trôi"))
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"lạc trôi", true},
+		{"LAC TROI", true},
+		{"troi lac", true},
+		{"lacj trooi", true},
+		{"la5c tro6i", true},
+		{"son tung", true},
+		{"", true},
+		{"lac roi xa", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.want, Match("Lạc Trôi - Sơn Tùng M-TP", tt.query))
+		})
+	}
+}